@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"villain-couch/agent/src/api"
+	"villain-couch/agent/src/config"
+	mediaplayer "villain-couch/agent/src/media-player"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/ff"
+	"villain-couch/common/logger"
+	re "villain-couch/common/regex"
+)
+
+// apiServer serves the local REST API, nil if it is disabled or could not be started.
+var apiServer *api.Server
+
+func startAPI(db *storage.DB, conf *config.Config) {
+	addr := conf.GetAPIAddress()
+	if addr == "" {
+		return
+	}
+
+	s := api.New(db, conf.APIToken, getNowPlaying)
+	if err := s.Start(addr); err != nil {
+		logger.Log.Warn("could not start api server", "error", err)
+		return
+	}
+	apiServer = s
+}
+
+// apiCommands returns the commands sent through the API, or nil (never ready) when it is disabled.
+func apiCommands() <-chan api.Command {
+	if apiServer == nil {
+		return nil
+	}
+	return apiServer.Commands()
+}
+
+var (
+	nowPlayingMu sync.RWMutex
+	nowPlaying   = api.NowPlaying{State: models.StateStopped}
+)
+
+// getNowPlaying returns what the last tick saw, it is called from the API handlers.
+func getNowPlaying() api.NowPlaying {
+	nowPlayingMu.RLock()
+	defer nowPlayingMu.RUnlock()
+	return nowPlaying
+}
+
+func setNowPlaying(status models.StatusMessage, currentFilepath string) {
+	np := api.NowPlaying{
+		Filepath: currentFilepath,
+		Filename: status.GetFilename(),
+		State:    status.GetState(),
+		Position: status.GetTime(),
+		Length:   status.GetLength(),
+	}
+	if np.Length > 0 {
+		np.Progress = float64(np.Position) * 100 / float64(np.Length)
+	}
+
+	nowPlayingMu.Lock()
+	nowPlaying = np
+	nowPlayingMu.Unlock()
+}
+
+// executeCommand runs a command sent through the API on the media player.
+// It runs in the main loop, like handleTick, since the media players are not safe for concurrent use.
+func executeCommand(player mediaplayer.MediaPlayer, cmd api.Command) error {
+	logger.Log.Info("api command", "action", cmd.Action, "file", cmd.File, "second", cmd.Second)
+
+	switch cmd.Action {
+	case api.ActionPlay:
+		if err := player.PlayFile(cmd.File); err != nil {
+			return err
+		}
+		if cmd.Second > 0 {
+			return player.SeekSecond(strconv.Itoa(cmd.Second))
+		}
+		return nil
+	case api.ActionNext:
+		return playNeighbour(player, true)
+	case api.ActionPrevious:
+		return playNeighbour(player, false)
+	case api.ActionSeek:
+		return player.SeekSecond(strconv.Itoa(cmd.Second))
+	case api.ActionPause:
+		return player.Pause(true)
+	case api.ActionResume:
+		return player.Pause(false)
+	default:
+		return fmt.Errorf("unknown action '%s'", cmd.Action)
+	}
+}
+
+// playNeighbour plays the next or previous episode of the current file.
+// The directory of the file and its season siblings are tried first, then the library index.
+// Going back, the previous episode of the same season in the library wins over the previous season on disk.
+func playNeighbour(player mediaplayer.MediaPlayer, next bool) error {
+	current := getNowPlaying().Filepath
+	if current == "" {
+		return fmt.Errorf("nothing is playing: %w", api.ErrNotFound)
+	}
+
+	if next {
+		if name, ok := re.GetNextEpisodeFilename(current); ok {
+			if _, err := os.Stat(name); err == nil {
+				return player.PlayFile(name)
+			}
+		}
+		found, err := findLibraryNeighbour(current, true)
+		if err != nil {
+			return err
+		}
+		return player.PlayFile(found)
+	}
+
+	local, ok := re.GetPreviousEpisodeFilename(current)
+	if ok && isSameSeason(local, current) {
+		return player.PlayFile(local)
+	}
+	found, err := findLibraryNeighbour(current, false)
+	switch {
+	case err == nil && (!ok || isSameSeason(found, current)):
+		return player.PlayFile(found)
+	case ok:
+		return player.PlayFile(local)
+	}
+	return err
+}
+
+// findLibraryNeighbour returns the next or previous episode of current in the library index,
+// api.ErrNotFound if there is none or it is not available.
+func findLibraryNeighbour(current string, next bool) (string, error) {
+	info, err := ff.ParseEpisodeInfo(current)
+	if err != nil {
+		return "", fmt.Errorf("'%s' is not an episode: %w", current, api.ErrNotFound)
+	}
+	showKey := ff.CanonicalShowKey(info.ShowName)
+
+	var found *models.LibraryFile
+	if next {
+		found, err = storage.GetDB().FindNextLibraryEpisode(showKey, info.Season, info.LastEpisode())
+	} else {
+		found, err = storage.GetDB().FindPreviousLibraryEpisode(showKey, info.Season, info.Episode)
+	}
+	if err != nil {
+		return "", err
+	}
+	if found == nil {
+		return "", fmt.Errorf("no episode of '%s' found: %w", info.ShowName, api.ErrNotFound)
+	}
+	if _, err := os.Stat(found.Filepath); err != nil {
+		return "", fmt.Errorf("'%s' is not available: %w", found.Filepath, api.ErrNotFound)
+	}
+	return found.Filepath, nil
+}
+
+// isSameSeason reports whether two files are episodes of the same season.
+func isSameSeason(path, other string) bool {
+	a, err := ff.ParseEpisodeInfo(path)
+	if err != nil {
+		return false
+	}
+	b, err := ff.ParseEpisodeInfo(other)
+	return err == nil && a.Season == b.Season
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+)
+
+// eventBuffer is how many events a slow client may fall behind before events are dropped for it.
+const eventBuffer = 64
+
+// keepAliveInterval is how often an idle event stream sends a comment, so proxies keep it open.
+const keepAliveInterval = 15 * time.Second
+
+// Publish sends an event to every connected event stream. It never blocks,
+// a client that does not keep up misses events. Internal events are not sent.
+func (s *Server) Publish(e models.PlaybackEvent) {
+	if e.IsInternal() {
+		return
+	}
+	s.clientsMu.Lock()
+	defer s.clientsMu.Unlock()
+	for c := range s.clients {
+		select {
+		case c <- e:
+		default:
+			logger.Log.Warn("event stream client is too slow, dropping event", "type", e.Type)
+		}
+	}
+}
+
+func (s *Server) subscribe() chan models.PlaybackEvent {
+	c := make(chan models.PlaybackEvent, eventBuffer)
+	s.clientsMu.Lock()
+	s.clients[c] = struct{}{}
+	s.clientsMu.Unlock()
+	return c
+}
+
+func (s *Server) unsubscribe(c chan models.PlaybackEvent) {
+	s.clientsMu.Lock()
+	delete(s.clients, c)
+	s.clientsMu.Unlock()
+}
+
+// handleEvents streams playback events as server-sent events until the client goes away.
+// ?types=started,completed limits the stream to some event types.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	var types []string
+	if v := r.URL.Query().Get("types"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(models.EventTypes, t) {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("unknown event type '%s', expected one of %s", t, strings.Join(models.EventTypes, ", ")))
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	// Tell the client right away that the stream is open.
+	if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil || rc.Flush() != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	write := func(e models.PlaybackEvent) error {
+		if len(types) > 0 && !slices.Contains(types, e.Type) {
+			return nil
+		}
+		data, err := json.Marshal(e)
+		if err != nil {
+			logger.Log.Warn("could not encode event", "type", e.Type, "error", err)
+			return nil
+		}
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		return err
+	}
+
+	for {
+		select {
+		case e := <-events:
+			if err := write(e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			// Deliver what was published right before closing, e.g. the exited event.
+			for {
+				select {
+				case e := <-events:
+					if write(e) != nil {
+						return
+					}
+				default:
+					_ = rc.Flush()
+					return
+				}
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/ff"
+	"villain-couch/common/logger"
+)
+
+// dateLayout is the format of the since and until parameters of /api/history, like --history-since.
+const dateLayout = "2006-01-02"
+
+type mediaFileJSON struct {
+	Filepath  string     `json:"filepath"`
+	Filename  string     `json:"filename"`
+	Position  int        `json:"position"`
+	Length    int        `json:"length"`
+	Watched   bool       `json:"watched"`
+	WatchedAt *time.Time `json:"watched_at,omitempty"`
+	PlayCount int        `json:"play_count"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type watchSessionJSON struct {
+	Filepath       string    `json:"filepath"`
+	Filename       string    `json:"filename"`
+	StartedAt      time.Time `json:"started_at"`
+	EndedAt        time.Time `json:"ended_at"`
+	StartSecond    int       `json:"start_second"`
+	EndSecond      int       `json:"end_second"`
+	TotalSeconds   int       `json:"total_seconds"`
+	WatchedSeconds int       `json:"watched_seconds"`
+	Completed      bool      `json:"completed"`
+}
+
+type workspaceJSON struct {
+	ID            int    `json:"id"`
+	DirectoryPath string `json:"directory_path"`
+	DirectoryName string `json:"directory_name"`
+}
+
+type libraryShowJSON struct {
+	ShowKey  string `json:"show_key"`
+	ShowName string `json:"show_name"`
+	Files    int    `json:"files"`
+}
+
+type libraryFileJSON struct {
+	Filepath string `json:"filepath"`
+	ShowName string `json:"show_name"`
+	Season   int    `json:"season"`
+	Episode  int    `json:"episode"`
+	Quality  int    `json:"quality"`
+}
+
+func (s *Server) handleNowPlaying(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.nowPlaying())
+}
+
+// handleProgress lists the files started but not finished, or the progress of a single ?file=.
+func (s *Server) handleProgress(w http.ResponseWriter, r *http.Request) {
+	if file := r.URL.Query().Get("file"); file != "" {
+		mf, err := s.db.GetMediaFile(file)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no progress for '%s'", file))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newMediaFileJSON(*mf))
+		return
+	}
+
+	files, err := s.db.GetInProgressMediaFiles()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]mediaFileJSON, 0, len(files))
+	for _, mf := range files {
+		out = append(out, newMediaFileJSON(mf))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleHistory lists watch sessions, narrowed down by show, since, until and limit like --history.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.WatchSessionFilter{Show: q.Get("show"), Limit: 20}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit '%s'", v))
+			return
+		}
+		filter.Limit = limit
+	}
+	if v := q.Get("since"); v != "" {
+		since, err := time.ParseInLocation(dateLayout, v, time.Local)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since date '%s', expected YYYY-MM-DD", v))
+			return
+		}
+		filter.Since = since
+	}
+	if v := q.Get("until"); v != "" {
+		until, err := time.ParseInLocation(dateLayout, v, time.Local)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid until date '%s', expected YYYY-MM-DD", v))
+			return
+		}
+		// Include the whole day.
+		filter.Until = until.AddDate(0, 0, 1)
+	}
+
+	sessions, err := s.db.GetWatchSessions(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]watchSessionJSON, 0, len(sessions))
+	for _, ws := range sessions {
+		out = append(out, watchSessionJSON{
+			Filepath:       ws.Filepath,
+			Filename:       ws.Filename,
+			StartedAt:      ws.StartedAt,
+			EndedAt:        ws.EndedAt,
+			StartSecond:    ws.StartSecond,
+			EndSecond:      ws.EndSecond,
+			TotalSeconds:   ws.TotalSeconds,
+			WatchedSeconds: ws.WatchedSeconds,
+			Completed:      ws.Completed,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleWorkspaces(w http.ResponseWriter, _ *http.Request) {
+	workspaces, err := s.db.GetWorkspaces()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]workspaceJSON, 0, len(workspaces))
+	for _, ws := range workspaces {
+		out = append(out, workspaceJSON{ID: ws.ID, DirectoryPath: ws.DirectoryPath, DirectoryName: ws.DirectoryName})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleLibrary lists the indexed shows, or the episodes of ?show=, optionally of a single &season=.
+func (s *Server) handleLibrary(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	show := q.Get("show")
+	if show == "" {
+		shows, err := s.db.GetLibraryShows()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		out := make([]libraryShowJSON, 0, len(shows))
+		for _, ls := range shows {
+			out = append(out, libraryShowJSON{ShowKey: ls.ShowKey, ShowName: ls.ShowName, Files: ls.Files})
+		}
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+
+	season := -1
+	if v := q.Get("season"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid season '%s'", v))
+			return
+		}
+		season = n
+	}
+
+	files, err := s.db.GetLibraryShowFiles(ff.CanonicalShowKey(show), season)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	out := make([]libraryFileJSON, 0, len(files))
+	for _, f := range files {
+		out = append(out, libraryFileJSON{Filepath: f.Filepath, ShowName: f.ShowName, Season: f.Season, Episode: f.Episode, Quality: f.Quality})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handlePlay plays {"file": "...", "start": <second>}.
+func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		File  string `json:"file"`
+		Start int    `json:"start"`
+	}
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if body.File == "" {
+		writeError(w, http.StatusBadRequest, errors.New("file is required"))
+		return
+	}
+	if body.Start < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("start must not be negative"))
+		return
+	}
+
+	file, err := filepath.Abs(body.File)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := os.Stat(file); err != nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("file '%s' %w", body.File, ErrNotFound))
+		return
+	}
+	s.runCommand(w, r, Command{Action: ActionPlay, File: file, Second: body.Start})
+}
+
+// handleSeek jumps to {"second": <second>} of the current file.
+func (s *Server) handleSeek(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Second *int `json:"second"`
+	}
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if body.Second == nil || *body.Second < 0 {
+		writeError(w, http.StatusBadRequest, errors.New("second is required and must not be negative"))
+		return
+	}
+	s.runCommand(w, r, Command{Action: ActionSeek, Second: *body.Second})
+}
+
+func (s *Server) handleAction(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.runCommand(w, r, Command{Action: action})
+	}
+}
+
+// handleMarkWatched marks {"file": "...", "watched": true} watched or unwatched.
+// Without a file the one playing now is marked, watched defaults to true.
+func (s *Server) handleMarkWatched(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		File    string `json:"file"`
+		Watched *bool  `json:"watched"`
+	}
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	file := s.nowPlaying().Filepath
+	if body.File != "" {
+		var err error
+		if file, err = filepath.Abs(body.File); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, err := os.Stat(file); err != nil {
+			writeError(w, http.StatusNotFound, fmt.Errorf("file '%s' %w", body.File, ErrNotFound))
+			return
+		}
+	}
+	if file == "" {
+		writeError(w, http.StatusBadRequest, errors.New("file is required when nothing is playing"))
+		return
+	}
+	watched := body.Watched == nil || *body.Watched
+
+	changed, err := s.db.SetMediaFilesWatched([]string{file}, watched)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	logger.Log.Info("api marked file", "path", file, "watched", watched, "changed", changed)
+	writeJSON(w, http.StatusOK, map[string]any{"filepath": file, "watched": watched, "changed": changed > 0})
+}
+
+func (s *Server) runCommand(w http.ResponseWriter, r *http.Request, cmd Command) {
+	status, err := s.send(r.Context(), cmd)
+	if err != nil {
+		logger.Log.Warn("api command failed", "action", cmd.Action, "error", err)
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.nowPlaying())
+}
+
+func newMediaFileJSON(mf models.MediaFile) mediaFileJSON {
+	out := mediaFileJSON{
+		Filepath:  mf.Filepath,
+		Filename:  mf.Filename,
+		Position:  mf.CurrentSecond,
+		Length:    mf.TotalSeconds,
+		Watched:   mf.Watched,
+		PlayCount: mf.PlayCount,
+		UpdatedAt: mf.UpdatedAt,
+	}
+	if !mf.WatchedAt.IsZero() {
+		out.WatchedAt = &mf.WatchedAt
+	}
+	return out
+}
+
+// readJSON decodes the request body into v, an empty body leaves v untouched.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Log.Warn("could not write api response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
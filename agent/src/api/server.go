@@ -0,0 +1,191 @@
+// Package api serves a small REST API on localhost while the agent runs,
+// so scripts and phone shortcuts can see what is playing and drive the media player.
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/logger"
+)
+
+// Actions a Command can ask the main loop for.
+const (
+	ActionPlay     = "play"
+	ActionNext     = "next"
+	ActionPrevious = "previous"
+	ActionSeek     = "seek"
+	ActionPause    = "pause"
+	ActionResume   = "resume"
+)
+
+// ErrNotFound is returned by a command whose file or episode does not exist, it is answered with 404.
+var ErrNotFound = errors.New("not found")
+
+// commandTimeout is how long a request waits for the main loop to run its command.
+const commandTimeout = 10 * time.Second
+
+// Command asks the main loop to do something with the media player.
+// The media player is not safe for concurrent use, so the HTTP handlers never call it themselves.
+// The result is sent on Done, which is buffered so the main loop never blocks on it.
+type Command struct {
+	Action string
+	File   string // ActionPlay
+	Second int    // ActionSeek, and the start position of ActionPlay
+	Done   chan error
+}
+
+// NowPlaying is what the media player did on the last tick.
+type NowPlaying struct {
+	Filepath string  `json:"filepath"`
+	Filename string  `json:"filename"`
+	State    string  `json:"state"`
+	Position int     `json:"position"`
+	Length   int     `json:"length"`
+	Progress float64 `json:"progress"` // percent of the file played
+}
+
+// Server answers API requests carrying the configured token.
+type Server struct {
+	db         *storage.DB
+	token      string
+	nowPlaying func() NowPlaying
+	commands   chan Command
+	timeout    time.Duration
+	srv        *http.Server
+
+	clientsMu sync.Mutex
+	clients   map[chan models.PlaybackEvent]struct{} // connected event streams
+	done      chan struct{}                          // closed by Close to end the event streams
+	closeOnce sync.Once
+}
+
+// New creates a server reading from db. nowPlaying is called from the HTTP handlers
+// and must be safe for concurrent use.
+func New(db *storage.DB, token string, nowPlaying func() NowPlaying) *Server {
+	return &Server{
+		db:         db,
+		token:      token,
+		nowPlaying: nowPlaying,
+		commands:   make(chan Command),
+		timeout:    commandTimeout,
+		clients:    make(map[chan models.PlaybackEvent]struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Commands returns the commands the main loop has to run.
+func (s *Server) Commands() <-chan Command {
+	return s.commands
+}
+
+// Handler returns the API routes behind the token check.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/now-playing", s.handleNowPlaying)
+	mux.HandleFunc("GET /api/progress", s.handleProgress)
+	mux.HandleFunc("GET /api/history", s.handleHistory)
+	mux.HandleFunc("GET /api/workspaces", s.handleWorkspaces)
+	mux.HandleFunc("GET /api/library", s.handleLibrary)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("POST /api/play", s.handlePlay)
+	mux.HandleFunc("POST /api/next", s.handleAction(ActionNext))
+	mux.HandleFunc("POST /api/previous", s.handleAction(ActionPrevious))
+	mux.HandleFunc("POST /api/seek", s.handleSeek)
+	mux.HandleFunc("POST /api/pause", s.handleAction(ActionPause))
+	mux.HandleFunc("POST /api/resume", s.handleAction(ActionResume))
+	mux.HandleFunc("POST /api/mark-watched", s.handleMarkWatched)
+	return s.authenticate(mux)
+}
+
+// Start listens on addr and serves the API in the background.
+func (s *Server) Start(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.srv = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Log.Error("api server stopped", "error", err)
+		}
+	}()
+	logger.Log.Info("api server listening", "address", ln.Addr().String())
+	return nil
+}
+
+// Close ends the event streams and stops the server, requests in flight get a moment to finish.
+// Calling it again does nothing.
+func (s *Server) Close() {
+	s.closeOnce.Do(s.close)
+}
+
+func (s *Server) close() {
+	close(s.done)
+	if s.srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := s.srv.Shutdown(ctx); err != nil {
+		logger.Log.Warn("could not close api server", "error", err)
+	}
+}
+
+// authenticate rejects requests without "Authorization: Bearer <token>".
+// Browsers cannot set headers on an EventSource, so the event stream takes the token as ?token= as well.
+// Other routes don't, the token would end up in shell histories and proxy logs.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok && r.Method == http.MethodGet && r.URL.Path == "/api/events" {
+			token = r.URL.Query().Get("token")
+			ok = token != ""
+		}
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// send hands a command to the main loop and waits for its result.
+func (s *Server) send(ctx context.Context, cmd Command) (int, error) {
+	cmd.Done = make(chan error, 1)
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	select {
+	case s.commands <- cmd:
+	case <-timer.C:
+		return http.StatusServiceUnavailable, errors.New("the agent is busy, try again")
+	case <-ctx.Done():
+		return http.StatusServiceUnavailable, ctx.Err()
+	}
+
+	select {
+	case err := <-cmd.Done:
+		switch {
+		case err == nil:
+			return http.StatusOK, nil
+		case errors.Is(err, ErrNotFound):
+			return http.StatusNotFound, err
+		default:
+			return http.StatusBadGateway, err
+		}
+	case <-timer.C:
+		return http.StatusServiceUnavailable, errors.New("the media player did not answer in time")
+	case <-ctx.Done():
+		return http.StatusServiceUnavailable, ctx.Err()
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T) (*Server, *storage.DB) {
+	db := storagetest.NewDB(t)
+
+	s := New(db, "secret", func() NowPlaying {
+		return NowPlaying{Filepath: "/shows/Dark.S01E01.mkv", State: models.StatePlaying, Position: 30, Length: 60, Progress: 50}
+	})
+	return s, db
+}
+
+func do(t *testing.T, s *Server, method, target, body string) (*httptest.ResponseRecorder, map[string]any) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	var out map[string]any
+	if strings.HasPrefix(strings.TrimSpace(rec.Body.String()), "{") {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	}
+	return rec, out
+}
+
+func TestAuthentication(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/now-playing", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, header)
+	}
+
+	// Only the event stream takes the token as a parameter.
+	for _, target := range []string{"/api/pause?token=secret", "/api/next?token=secret"} {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, target)
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/now-playing?token=secret", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, out := do(t, s, http.MethodGet, "/api/now-playing", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "/shows/Dark.S01E01.mkv", out["filepath"])
+	assert.Equal(t, 50.0, out["progress"])
+}
+
+func TestCloseTwice(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.Close()
+	assert.NotPanics(t, s.Close)
+}
+
+func TestProgressAndMarkWatched(t *testing.T) {
+	s, db := newTestServer(t)
+	now := time.Now()
+	require.NoError(t, db.SetMediaFile(models.MediaFile{
+		Filepath: "/shows/Dark.S01E01.mkv", Filename: "Dark.S01E01.mkv", TotalSeconds: 60, CurrentSecond: 30, CreatedAt: now, UpdatedAt: now,
+	}))
+
+	rec, _ := do(t, s, http.MethodGet, "/api/progress", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var files []mediaFileJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &files))
+	require.Len(t, files, 1)
+	assert.Equal(t, 30, files[0].Position)
+
+	rec, _ = do(t, s, http.MethodGet, "/api/progress?file=/shows/missing.mkv", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Without a file, the one playing now is marked.
+	rec, out := do(t, s, http.MethodPost, "/api/mark-watched", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, true, out["changed"])
+
+	mf, err := db.GetMediaFile("/shows/Dark.S01E01.mkv")
+	require.NoError(t, err)
+	assert.True(t, mf.Watched)
+
+	// Relative paths are marked by their absolute path, like the CLI does.
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dark.S01E02.mkv"), nil, 0o644))
+	t.Chdir(dir)
+	file, err := filepath.Abs("Dark.S01E02.mkv")
+	require.NoError(t, err)
+	rec, out = do(t, s, http.MethodPost, "/api/mark-watched", `{"file": "Dark.S01E02.mkv"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, file, out["filepath"])
+	mf, err = db.GetMediaFile(file)
+	require.NoError(t, err)
+	assert.True(t, mf.Watched)
+
+	rec, _ = do(t, s, http.MethodPost, "/api/mark-watched", `{"file": "`+filepath.ToSlash(file)+`", "watched": false}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	mf, err = db.GetMediaFile(file)
+	require.NoError(t, err)
+	assert.False(t, mf.Watched)
+
+	rec, _ = do(t, s, http.MethodPost, "/api/mark-watched", `{"file": "/shows/missing.mkv"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	_, err = db.GetMediaFile("/shows/missing.mkv")
+	assert.Error(t, err)
+}
+
+func TestCommandsGoThroughTheMainLoop(t *testing.T) {
+	s, _ := newTestServer(t)
+	file := filepath.Join(t.TempDir(), "Dark.S01E02.mkv")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	received := make(chan Command, 1)
+	go func() {
+		cmd := <-s.Commands()
+		received <- cmd
+		cmd.Done <- nil
+	}()
+
+	rec, _ := do(t, s, http.MethodPost, "/api/play", `{"file": "`+filepath.ToSlash(file)+`", "start": 42}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	cmd := <-received
+	assert.Equal(t, ActionPlay, cmd.Action)
+	assert.Equal(t, file, filepath.FromSlash(cmd.File))
+	assert.Equal(t, 42, cmd.Second)
+
+	go func() {
+		cmd := <-s.Commands()
+		cmd.Done <- ErrNotFound
+	}()
+	rec, _ = do(t, s, http.MethodPost, "/api/next", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCommandValidation(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.timeout = 50 * time.Millisecond
+
+	rec, _ := do(t, s, http.MethodPost, "/api/seek", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = do(t, s, http.MethodPost, "/api/seek", `{"second": "ten"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec, _ = do(t, s, http.MethodPost, "/api/play", `{"file": "/shows/missing.mkv"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec, _ = do(t, s, http.MethodGet, "/api/history?since=yesterday", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Nobody runs the main loop.
+	rec, _ = do(t, s, http.MethodPost, "/api/pause", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestEventStream(t *testing.T) {
+	s, _ := newTestServer(t)
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+
+	rec, _ := do(t, s, http.MethodGet, "/api/events?types=started,bogus", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// EventSource cannot send headers, the token is accepted as a parameter.
+	resp, err := http.Get(srv.URL + "/api/events?types=started,completed&token=secret")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(resp.Body)
+	require.True(t, lines.Scan())
+	assert.Equal(t, ": connected", lines.Text())
+
+	at := time.Now()
+	s.Publish(models.NewPlaybackEvent(models.EventProgress, "/shows/Dark.S01E01.mkv", "Dark.S01E01.mkv", 10, 60, at))
+	s.Publish(models.NewPlaybackEvent(models.EventCompleted, "/shows/Dark.S01E01.mkv", "Dark.S01E01.mkv", 59, 60, at))
+
+	var got []string
+	for len(got) < 2 && lines.Scan() {
+		if lines.Text() != "" {
+			got = append(got, lines.Text())
+		}
+	}
+	require.Len(t, got, 2)
+	assert.Equal(t, "event: completed", got[0])
+
+	var e models.PlaybackEvent
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(got[1], "data: ")), &e))
+	assert.Equal(t, 59, e.Position)
+	assert.Equal(t, "/shows/Dark.S01E01.mkv", e.Filepath)
+}
+
+func TestInternalEventsAreNotStreamed(t *testing.T) {
+	s, _ := newTestServer(t)
+	c := s.subscribe()
+	defer s.unsubscribe(c)
+
+	s.Publish(models.NewPlaybackEvent(models.EventTick, "/shows/Dark.S01E01.mkv", "Dark.S01E01.mkv", 10, 60, time.Now()))
+	s.Publish(models.NewPlaybackEvent(models.EventPaused, "/shows/Dark.S01E01.mkv", "Dark.S01E01.mkv", 10, 60, time.Now()))
+	require.Len(t, c, 1)
+	assert.Equal(t, models.EventPaused, (<-c).Type)
+}
@@ -30,6 +30,18 @@ func NewCommandRunnerForVLC(args VLCRunnerArguments) *CommandRunner {
 	}
 }
 
+func NewCommandRunnerForMPV(args MPVRunnerArguments) *CommandRunner {
+	return &CommandRunner{
+		cmd: PrepareMPVCommand(args),
+	}
+}
+
+func NewCommandRunnerForHook(args HookRunnerArguments) *CommandRunner {
+	return &CommandRunner{
+		cmd: PrepareHookCommand(args),
+	}
+}
+
 // runs the command in the background. It is safe to call this function multiple times.
 func (c *CommandRunner) Start() error {
 	c.mu.Lock()
@@ -40,9 +52,14 @@ func (c *CommandRunner) Start() error {
 		return fmt.Errorf("command has already been started")
 	}
 
-	// Pipe the command's stdout and stderr to the parent process to see its output.
-	c.cmd.Stdout = os.Stdout
-	c.cmd.Stderr = os.Stderr
+	// Pipe the command's stdout and stderr to the parent process to see its output,
+	// unless the command captures it.
+	if c.cmd.Stdout == nil {
+		c.cmd.Stdout = os.Stdout
+	}
+	if c.cmd.Stderr == nil {
+		c.cmd.Stderr = os.Stderr
+	}
 
 	if err := c.cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start command: %w", err)
@@ -81,6 +98,20 @@ func (c *CommandRunner) Stop() error {
 	return nil
 }
 
+// kills the running command right away, for commands that ignore the interrupt.
+func (c *CommandRunner) Kill() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cmd.Process == nil {
+		return fmt.Errorf("command is not running")
+	}
+	if err := c.cmd.Process.Kill(); err != nil {
+		return fmt.Errorf("failed to kill process: %w", err)
+	}
+	return nil
+}
+
 // returns a channel that is closed when the command finishes.
 // The value sent on the channel is the error result from cmd.Wait().
 func (c *CommandRunner) Done() <-chan error {
@@ -11,6 +11,27 @@ type CLIFlags struct {
 	FindNext     bool
 	MediaFile    str.Str
 	AddWorkspace str.Str
+	ListWS       bool
+	RemoveWS     str.Str
+	RenameWS     str.Str
+	WSName       str.Str
+	History      bool
+	HistoryShow  str.Str
+	HistorySince str.Str
+	HistoryUntil str.Str
+	HistoryLimit int
+	Scan         bool
+	Rescan       bool
+	MatchDebug   str.Str
+	AliasAdd     str.Str
+	AliasOf      str.Str
+	AliasRemove  str.Str
+	AliasList    bool
+	MarkWatched  str.Str
+	MarkUnwatch  str.Str
+	MarkSeason   int
+	Continue     bool
+	Show         str.Str
 }
 
 var cliFlags *CLIFlags
@@ -24,14 +45,37 @@ func GetFlags() *CLIFlags {
 }
 
 func parseFlags() *CLIFlags {
-	var Version, Verbose, FindNext bool
-	var MF, AW string
+	var Version, Verbose, FindNext, History, LWS, Scan, Rescan, AL, Continue bool
+	var MF, AW, RmWS, RnWS, WSN, HS, HSince, HUntil, MD, AA, AO, AR string
+	var MW, MU, Show string
+	var HL, MS int
 
 	flag.BoolVar(&Version, "version", false, "prints version (terminates immediately)")
 	flag.BoolVar(&Verbose, "verbose", false, "prints info level logs")
 	flag.BoolVar(&FindNext, "find-next", false, "tries to find next episode when there is nothing else to play.")
 	flag.StringVar(&MF, "file", "", "media file to play")
+	flag.StringVar(&Show, "show", "", "plays the in-progress or next unwatched episode of a show, e.g. -show office")
+	flag.BoolVar(&Continue, "continue", false, "lists in-progress files by show and asks which one to resume.")
 	flag.StringVar(&AW, "ws", "", "add directory to workspace, will close agent after all operations.")
+	flag.BoolVar(&LWS, "ws-list", false, "lists workspaces, will close agent after all operations.")
+	flag.StringVar(&RmWS, "ws-remove", "", "removes workspace by id, path or name, will close agent after all operations.")
+	flag.StringVar(&RnWS, "ws-rename", "", "renames workspace given by id, path or name to -ws-name, will close agent after all operations.")
+	flag.StringVar(&WSN, "ws-name", "", "new name for -ws-rename")
+	flag.BoolVar(&History, "history", false, "lists recent watch sessions, will close agent after all operations.")
+	flag.StringVar(&HS, "history-show", "", "only list watch sessions of files matching this show name")
+	flag.StringVar(&HSince, "history-since", "", "only list watch sessions started on or after this date (YYYY-MM-DD)")
+	flag.StringVar(&HUntil, "history-until", "", "only list watch sessions started on or before this date (YYYY-MM-DD)")
+	flag.IntVar(&HL, "history-limit", 20, "maximum number of watch sessions to list")
+	flag.BoolVar(&Scan, "scan", false, "updates the library index of all workspaces, will close agent after all operations.")
+	flag.BoolVar(&Rescan, "rescan", false, "rebuilds the library index of all workspaces from scratch, will close agent after all operations.")
+	flag.StringVar(&MD, "match-debug", "", "shows how workspace directories score against an episode filename or show name, will close agent after all operations.")
+	flag.StringVar(&AA, "alias-add", "", "adds another name of the show given with -alias-of, will close agent after all operations.")
+	flag.StringVar(&AO, "alias-of", "", "show name for -alias-add")
+	flag.StringVar(&AR, "alias-remove", "", "removes a show alias, will close agent after all operations.")
+	flag.BoolVar(&AL, "alias-list", false, "lists show aliases, will close agent after all operations.")
+	flag.StringVar(&MW, "mark-watched", "", "marks a file, a directory or a show as watched, will close agent after all operations.")
+	flag.StringVar(&MU, "mark-unwatched", "", "marks a file, a directory or a show as unwatched, will close agent after all operations.")
+	flag.IntVar(&MS, "mark-season", -1, "only mark this season of the show given to -mark-watched or -mark-unwatched")
 	flag.Parse()
 
 	return &CLIFlags{
@@ -40,5 +84,26 @@ func parseFlags() *CLIFlags {
 		Verbose:      Verbose,
 		MediaFile:    str.Str(MF),
 		AddWorkspace: str.Str(AW),
+		ListWS:       LWS,
+		RemoveWS:     str.Str(RmWS),
+		RenameWS:     str.Str(RnWS),
+		WSName:       str.Str(WSN),
+		History:      History,
+		HistoryShow:  str.Str(HS),
+		HistorySince: str.Str(HSince),
+		HistoryUntil: str.Str(HUntil),
+		HistoryLimit: HL,
+		Scan:         Scan,
+		Rescan:       Rescan,
+		MatchDebug:   str.Str(MD),
+		AliasAdd:     str.Str(AA),
+		AliasOf:      str.Str(AO),
+		AliasRemove:  str.Str(AR),
+		AliasList:    AL,
+		MarkWatched:  str.Str(MW),
+		MarkUnwatch:  str.Str(MU),
+		MarkSeason:   MS,
+		Continue:     Continue,
+		Show:         str.Str(Show),
 	}
 }
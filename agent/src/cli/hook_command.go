@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// hookWaitDelay is how long the output of a finished or stopped hook is read,
+// a child process it left behind must not keep the agent waiting.
+const hookWaitDelay = 2 * time.Second
+
+type HookRunnerArguments struct {
+	Path string
+	// Env is added to the environment of the agent, as KEY=value.
+	Env   []string
+	Stdin []byte
+	// Output receives stdout and stderr of the hook.
+	Output io.Writer
+}
+
+func PrepareHookRunnerArguments(Path string, Env []string, Stdin []byte, Output io.Writer) HookRunnerArguments {
+	return HookRunnerArguments{
+		Path:   Path,
+		Env:    Env,
+		Stdin:  Stdin,
+		Output: Output,
+	}
+}
+
+// PrepareHookCommand builds the command of a user hook script.
+func PrepareHookCommand(args HookRunnerArguments) *exec.Cmd {
+	cmd := exec.Command(args.Path)
+	cmd.Env = append(os.Environ(), args.Env...)
+	cmd.Stdin = bytes.NewReader(args.Stdin)
+	cmd.Stdout = args.Output
+	cmd.Stderr = args.Output
+	cmd.WaitDelay = hookWaitDelay
+	return cmd
+}
@@ -0,0 +1,38 @@
+package cli
+
+import "os/exec"
+
+type MPVRunnerArguments struct {
+	MPVPath   string
+	MediaFile string
+	StartTime string
+	IPCSocket string
+}
+
+func PrepareMPVRunnerArguments(MPVPath, MediaFile, StartTime, IPCSocket string) MPVRunnerArguments {
+	return MPVRunnerArguments{
+		MPVPath:   MPVPath,
+		MediaFile: MediaFile,
+		StartTime: StartTime,
+		IPCSocket: IPCSocket,
+	}
+}
+
+// PrepareMPVCommand builds the mpv command line.
+// --keep-open and --idle keep mpv alive after a file ends, so the agent can load the next one
+// the same way it does with VLC.
+func PrepareMPVCommand(args MPVRunnerArguments) *exec.Cmd {
+	arr := []string{
+		args.MediaFile,
+		"--input-ipc-server=" + args.IPCSocket,
+		"--keep-open=yes",
+		"--idle=yes",
+		"--force-window=yes",
+	}
+	if args.StartTime != "" {
+		arr = append(arr, "--start="+args.StartTime)
+	}
+
+	cmd := exec.Command(args.MPVPath, arr...)
+	return cmd
+}
@@ -0,0 +1,76 @@
+package operations
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"villain-couch/agent/src/library"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/ff"
+)
+
+// resolveMediaFiles returns the video files a target stands for: the file itself, every video file below a directory,
+// or the indexed episodes of a show name, optionally of a single season (negative for all seasons).
+func resolveMediaFiles(db *storage.DB, target string, season int) ([]string, error) {
+	if target == "" {
+		return nil, fmt.Errorf("no file, directory or show given")
+	}
+
+	if info, err := os.Stat(target); err == nil {
+		path, err := filepath.Abs(target)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve path '%s': %w", target, err)
+		}
+		if !info.IsDir() {
+			return []string{path}, nil
+		}
+		return videoFilesBelow(path)
+	}
+
+	showKey := ff.CanonicalShowKey(target)
+	var files []models.LibraryFile
+	err := library.NewScanner(db).Lookup(func() (bool, error) {
+		var err error
+		files, err = db.GetLibraryShowFiles(showKey, season)
+		return len(files) > 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		if season >= 0 {
+			return nil, fmt.Errorf("no file, directory or episodes of season %d found for '%s'", season, target)
+		}
+		return nil, fmt.Errorf("no file, directory or show found for '%s'", target)
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Filepath)
+	}
+	return paths, nil
+}
+
+// videoFilesBelow returns the video files in dir and its subdirectories.
+func videoFilesBelow(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && ff.IsVideoFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory '%s': %w", dir, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no video files found in '%s'", dir)
+	}
+	return paths, nil
+}
@@ -0,0 +1,48 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveMediaFiles(t *testing.T) {
+	db := storagetest.NewDB(t)
+	root := t.TempDir()
+	for _, name := range []string{"Dark/Season 1/Dark.S01E01.mkv", "Dark/Season 1/Dark.S01E02.mkv", "Dark/Season 2/Dark.S02E01.mkv", "Dark/notes.txt"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+	now := time.Now()
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: root, DirectoryName: "tv", CreatedAt: now, UpdatedAt: now}))
+
+	file := filepath.Join(root, "Dark", "Season 1", "Dark.S01E01.mkv")
+	files, err := resolveMediaFiles(db, file, -1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{file}, files)
+
+	files, err = resolveMediaFiles(db, filepath.Join(root, "Dark"), -1)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	// Show names are looked up in the library index, which is scanned when it knows nothing yet.
+	files, err = resolveMediaFiles(db, "dark", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "Dark", "Season 2", "Dark.S02E01.mkv")}, files)
+
+	files, err = resolveMediaFiles(db, "Dark", -1)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+
+	_, err = resolveMediaFiles(db, "Dark", 3)
+	assert.Error(t, err)
+	_, err = resolveMediaFiles(db, "Dark Matter", -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"villain-couch/common/logger"
+)
+
+type AddShowAlias struct {
+	Operation
+	Alias     string
+	Canonical string
+}
+
+func (a AddShowAlias) Priority() int {
+	return OrderMedium
+}
+
+func (a AddShowAlias) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a AddShowAlias) Name() string {
+	return "Add Show Alias"
+}
+
+func (a AddShowAlias) Run() error {
+	canonical := strings.TrimSpace(a.Canonical)
+	if canonical == "" {
+		logger.Log.Error("no show name given, use -alias-of")
+		return errors.New("no show name given")
+	}
+
+	if err := a.Database.AddShowAlias(strings.TrimSpace(a.Alias), canonical); err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	fmt.Printf("Added alias: %s -> %s\n", a.Alias, canonical)
+	return nil
+}
+
+func (a AddShowAlias) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
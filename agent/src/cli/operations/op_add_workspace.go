@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"time"
+	"villain-couch/agent/src/library"
 	"villain-couch/agent/src/models"
 	"villain-couch/common/fs"
 	"villain-couch/common/logger"
@@ -42,6 +43,15 @@ func (a AddWorkspace) Run() error {
 		logger.Log.Error(a.DefaultError(), "error", err)
 		return err
 	}
+
+	// Index the new workspace right away so the first -find-next does not have to.
+	added, err := findWorkspace(a.Database, a.DirPath)
+	if err != nil {
+		return err
+	}
+	if _, err := library.NewScanner(a.Database).ScanWorkspace(added); err != nil {
+		logger.Log.Warn("Could not index workspace, it will be scanned again later", "error", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,193 @@
+package operations
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/ff"
+	"villain-couch/common/fs"
+	"villain-couch/common/logger"
+	"villain-couch/common/str"
+)
+
+var errNothingPicked = errors.New("nothing picked")
+
+// Continue lists the in-progress files grouped by show and lets the user pick the one to resume.
+type Continue struct {
+	Operation
+	// File is the -file flag, picking and giving a file do not go together.
+	File string
+}
+
+func (a Continue) Priority() int {
+	return OrderHigh
+}
+
+func (a Continue) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a Continue) Name() string {
+	return "Continue Watching"
+}
+
+func (a Continue) Run() error {
+	if a.File != "" {
+		logger.Log.Error("-continue and -file cannot be used together")
+		return errors.New("-continue and -file cannot be used together")
+	}
+
+	files, err := a.Database.GetInProgressMediaFiles()
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	// Files on a drive that is not plugged in cannot be resumed.
+	var available []models.MediaFile
+	for _, f := range files {
+		if fs.FileExists(f.Filepath) {
+			available = append(available, f)
+		}
+	}
+
+	shows := groupByShow(available)
+	if len(shows) == 0 {
+		fmt.Println("Nothing to continue, every file is either watched or was not started.")
+		return nil
+	}
+
+	picked, err := pickMediaFile(os.Stdin, os.Stdout, shows)
+	if errors.Is(err, errNothingPicked) {
+		logger.Log.Warn("Nothing picked.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	a.Options.MediaFilePath = picked.Filepath
+	a.Options.MediaFileStartTime = strconv.Itoa(picked.CurrentSecond)
+	logger.Log.Info("Continuing", "path", picked.Filepath, "second", picked.CurrentSecond)
+	return nil
+}
+
+// Finalize closes the agent when nothing was picked to continue.
+func (a Continue) Finalize() {
+	if a.Options.MediaFilePath != "" {
+		return
+	}
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
+
+// showGroup is the in-progress files of a show, most recent first.
+type showGroup struct {
+	name  string
+	files []models.MediaFile
+	infos []ff.EpisodeInfo // parsed from files, zero for files that are no episode
+}
+
+// groupByShow groups files by their canonical show, keeping the order of files.
+// Files that are no episode, e.g. movies, are a group of their own.
+func groupByShow(files []models.MediaFile) []showGroup {
+	var groups []showGroup
+	index := make(map[string]int)
+	for _, f := range files {
+		name, key := ff.StripExtension(f.Filename), f.Filepath
+		info, err := ff.ParseEpisodeInfo(f.Filepath)
+		if err == nil && info.ShowName != "" {
+			name, key = info.ShowName, ff.CanonicalShowKey(info.ShowName)
+		} else {
+			info = ff.EpisodeInfo{}
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, showGroup{name: name})
+		}
+		groups[i].files = append(groups[i].files, f)
+		groups[i].infos = append(groups[i].infos, info)
+	}
+	return groups
+}
+
+// pickMediaFile prints the groups with a number in front of every file and reads the chosen number from in.
+// An empty answer picks the most recent file.
+func pickMediaFile(in io.Reader, out io.Writer, groups []showGroup) (models.MediaFile, error) {
+	var choices []models.MediaFile
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s\n", g.name)
+		for i, f := range g.files {
+			choices = append(choices, f)
+			fmt.Fprintf(w, "  %d)\t%s\t%s\t%s\t%s\n",
+				len(choices),
+				episodeLabel(g.infos[i]),
+				progressLabel(f),
+				f.UpdatedAt.Local().Format("2006-01-02 15:04"),
+				f.Filename,
+			)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return models.MediaFile{}, err
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprintf(out, "Pick a number (enter for 1, q to quit): ")
+		line, err := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if err != nil && answer == "" {
+			fmt.Fprintln(out)
+			return models.MediaFile{}, errNothingPicked
+		}
+
+		switch answer {
+		case "":
+			return choices[0], nil
+		case "q", "Q":
+			return models.MediaFile{}, errNothingPicked
+		}
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], nil
+		}
+		fmt.Fprintf(out, "'%s' is not a number between 1 and %d.\n", answer, len(choices))
+		if err != nil {
+			return models.MediaFile{}, errNothingPicked
+		}
+	}
+}
+
+// episodeLabel names the episode of a file, e.g. S02E03 or 2026-03-14, empty if it is no episode.
+func episodeLabel(info ff.EpisodeInfo) string {
+	switch {
+	case info.ShowName == "":
+		return ""
+	case !info.AirDate.IsZero():
+		return info.AirDate.Format("2006-01-02")
+	case info.AbsoluteEpisode > 0:
+		return fmt.Sprintf("E%02d", info.AbsoluteEpisode)
+	case info.LastEpisode() > info.Episode:
+		return fmt.Sprintf("S%02dE%02d-E%02d", info.Season, info.Episode, info.LastEpisode())
+	}
+	return fmt.Sprintf("S%02dE%02d", info.Season, info.Episode)
+}
+
+// progressLabel is how far a file was watched, e.g. "42%", or the position if the length is unknown.
+func progressLabel(f models.MediaFile) string {
+	if f.TotalSeconds <= 0 {
+		return str.Clock(f.CurrentSecond)
+	}
+	return fmt.Sprintf("%d%%", f.CurrentSecond*100/f.TotalSeconds)
+}
@@ -0,0 +1,57 @@
+package operations
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContinueGroupsInProgressFilesByShow(t *testing.T) {
+	db := storagetest.NewDB(t)
+	files := []models.MediaFile{
+		{Filepath: "/tv/Dark/Dark.S01E03.mkv", Filename: "Dark.S01E03.mkv", TotalSeconds: 3000, CurrentSecond: 1500},
+		{Filepath: "/tv/The Office/The.Office.S02E03.mkv", Filename: "The.Office.S02E03.mkv", TotalSeconds: 1300, CurrentSecond: 650},
+		{Filepath: "/movies/Inception.mkv", Filename: "Inception.mkv", TotalSeconds: 8800, CurrentSecond: 4400},
+		{Filepath: "/tv/Dark/Dark.S01E05.mkv", Filename: "Dark.S01E05.mkv", TotalSeconds: 3000, CurrentSecond: 300},
+		{Filepath: "/tv/Dark/Dark.S01E06.mkv", Filename: "Dark.S01E06.mkv", TotalSeconds: 3000},
+	}
+	for _, f := range files {
+		require.NoError(t, db.SetMediaFile(f))
+		time.Sleep(5 * time.Millisecond)
+	}
+	_, err := db.SetMediaFilesWatched([]string{"/tv/The Office/The.Office.S02E03.mkv"}, true)
+	require.NoError(t, err)
+
+	inProgress, err := db.GetInProgressMediaFiles()
+	require.NoError(t, err)
+	require.Len(t, inProgress, 3)
+
+	groups := groupByShow(inProgress)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "Dark", groups[0].name)
+	assert.Len(t, groups[0].files, 2)
+	assert.Equal(t, "Dark.S01E05.mkv", groups[0].files[0].Filename)
+	assert.Equal(t, "Inception", groups[1].name)
+
+	var out bytes.Buffer
+	picked, err := pickMediaFile(strings.NewReader("7\n3\n"), &out, groups)
+	require.NoError(t, err)
+	assert.Equal(t, "/movies/Inception.mkv", picked.Filepath)
+	assert.Contains(t, out.String(), "S01E05  10%")
+	assert.Contains(t, out.String(), "'7' is not a number between 1 and 3")
+
+	picked, err = pickMediaFile(strings.NewReader("\n"), &out, groups)
+	require.NoError(t, err)
+	assert.Equal(t, "/tv/Dark/Dark.S01E05.mkv", picked.Filepath)
+
+	_, err = pickMediaFile(strings.NewReader("q\n"), &out, groups)
+	assert.ErrorIs(t, err, errNothingPicked)
+	_, err = pickMediaFile(strings.NewReader(""), &out, groups)
+	assert.ErrorIs(t, err, errNothingPicked)
+}
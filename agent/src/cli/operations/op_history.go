@@ -0,0 +1,89 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+	"villain-couch/common/str"
+)
+
+const historyDateLayout = "2006-01-02"
+
+type History struct {
+	Operation
+	Show         string
+	Since, Until string // YYYY-MM-DD, both inclusive
+	Limit        int
+}
+
+func (a History) Priority() int {
+	return OrderMedium
+}
+
+func (a History) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a History) Name() string {
+	return "Watch History"
+}
+
+func (a History) Run() error {
+	filter := models.WatchSessionFilter{Show: a.Show, Limit: a.Limit}
+
+	if a.Since != "" {
+		since, err := time.ParseInLocation(historyDateLayout, a.Since, time.Local)
+		if err != nil {
+			logger.Log.Error("invalid history-since date, expected YYYY-MM-DD", "since", a.Since)
+			return fmt.Errorf("invalid history-since date '%s': %w", a.Since, err)
+		}
+		filter.Since = since
+	}
+
+	if a.Until != "" {
+		until, err := time.ParseInLocation(historyDateLayout, a.Until, time.Local)
+		if err != nil {
+			logger.Log.Error("invalid history-until date, expected YYYY-MM-DD", "until", a.Until)
+			return fmt.Errorf("invalid history-until date '%s': %w", a.Until, err)
+		}
+		// Include the whole day.
+		filter.Until = until.AddDate(0, 0, 1)
+	}
+
+	sessions, err := a.Database.GetWatchSessions(filter)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("No watch sessions found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STARTED\tWATCHED\tPOSITION\tCOMPLETED\tFILE")
+	for _, s := range sessions {
+		completed := ""
+		if s.Completed {
+			completed = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s -> %s / %s\t%s\t%s\n",
+			s.StartedAt.Local().Format("2006-01-02 15:04"),
+			str.Clock(s.WatchedSeconds),
+			str.Clock(s.StartSecond), str.Clock(s.EndSecond), str.Clock(s.TotalSeconds),
+			completed,
+			s.Filename,
+		)
+	}
+	return w.Flush()
+}
+
+func (a History) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
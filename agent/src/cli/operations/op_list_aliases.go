@@ -0,0 +1,51 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"villain-couch/common/logger"
+)
+
+type ListShowAliases struct {
+	Operation
+}
+
+// Priority is low so the list reflects aliases added or removed in the same run.
+func (a ListShowAliases) Priority() int {
+	return OrderLow
+}
+
+func (a ListShowAliases) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a ListShowAliases) Name() string {
+	return "List Show Aliases"
+}
+
+func (a ListShowAliases) Run() error {
+	aliases, err := a.Database.GetShowAliases()
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	if len(aliases) == 0 {
+		fmt.Println("No show aliases found. Add one with -alias-add <alias> -alias-of <show>.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SHOW\tALIAS")
+	for _, alias := range aliases {
+		fmt.Fprintf(w, "%s\t%s\n", alias.Canonical, alias.Alias)
+	}
+	return w.Flush()
+}
+
+func (a ListShowAliases) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
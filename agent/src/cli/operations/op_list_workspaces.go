@@ -0,0 +1,51 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"villain-couch/common/logger"
+)
+
+type ListWorkspaces struct {
+	Operation
+}
+
+// Priority is low so the list reflects workspaces added, renamed or removed in the same run.
+func (a ListWorkspaces) Priority() int {
+	return OrderLow
+}
+
+func (a ListWorkspaces) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a ListWorkspaces) Name() string {
+	return "List Workspaces"
+}
+
+func (a ListWorkspaces) Run() error {
+	workspaces, err := a.Database.GetWorkspaces()
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	if len(workspaces) == 0 {
+		fmt.Println("No workspaces found. Add one with -ws <directory>.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPATH")
+	for _, ws := range workspaces {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", ws.ID, ws.DirectoryName, ws.DirectoryPath)
+	}
+	return w.Flush()
+}
+
+func (a ListWorkspaces) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
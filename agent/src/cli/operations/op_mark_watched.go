@@ -0,0 +1,58 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"villain-couch/common/logger"
+)
+
+// MarkWatched marks a file, every video file in a directory, or the episodes of a show as watched or unwatched.
+type MarkWatched struct {
+	Operation
+	Target string
+	// Season narrows a show name down to one season, negative for all seasons.
+	Season  int
+	Watched bool
+}
+
+func (a MarkWatched) Priority() int {
+	return OrderMedium
+}
+
+func (a MarkWatched) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a MarkWatched) Name() string {
+	if a.Watched {
+		return "Mark Watched"
+	}
+	return "Mark Unwatched"
+}
+
+func (a MarkWatched) Run() error {
+	files, err := resolveMediaFiles(a.Database, a.Target, a.Season)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	changed, err := a.Database.SetMediaFilesWatched(files, a.Watched)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	state := "unwatched"
+	if a.Watched {
+		state = "watched"
+	}
+	fmt.Printf("Marked %d of %d files %s, the others already were.\n", changed, len(files), state)
+	return nil
+}
+
+func (a MarkWatched) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
@@ -0,0 +1,85 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"villain-couch/common/ff"
+	"villain-couch/common/logger"
+)
+
+// MatchDebug shows how every workspace directory scores against a show, to tune the match threshold.
+type MatchDebug struct {
+	Operation
+	// Target is an episode filename or a show name.
+	Target string
+}
+
+func (a MatchDebug) Priority() int {
+	return OrderLow
+}
+
+func (a MatchDebug) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a MatchDebug) Name() string {
+	return "Match Debug"
+}
+
+func (a MatchDebug) Run() error {
+	workspaces, err := a.Database.GetWorkspaces()
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+	if len(workspaces) == 0 {
+		return errors.New("no workspace found")
+	}
+
+	show := a.Target
+	if info, err := ff.ParseEpisodeInfo(a.Target); err == nil {
+		show = info.ShowName
+	}
+
+	fmt.Printf("Show: %s\nTokens: %s\nThreshold: %.2f\n", show, strings.Join(ff.ShowTokens(show), " "), ff.MatchThreshold())
+	for _, ws := range workspaces {
+		fmt.Printf("\n[%s] %s\n", ws.DirectoryName, ws.DirectoryPath)
+		matches, err := ff.RankDirectories(ws.DirectoryPath, show)
+		if err != nil {
+			fmt.Printf("  could not read workspace: %v\n", err)
+			continue
+		}
+		if err := printMatches(os.Stdout, matches); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printMatches(out io.Writer, matches []ff.ShowMatch) error {
+	if len(matches) == 0 {
+		_, err := fmt.Fprintln(out, "  (no directories)")
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  SCORE\tMATCH\tDIRECTORY\tTOKENS\tREASON")
+	for _, m := range matches {
+		verdict := "no"
+		if m.Matched {
+			verdict = "yes"
+		}
+		fmt.Fprintf(w, "  %.2f\t%s\t%s\t%s\t%s\n", m.Score, verdict, m.Name, strings.Join(m.Tokens, " "), m.Reason())
+	}
+	return w.Flush()
+}
+
+func (a MatchDebug) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
@@ -3,9 +3,10 @@ package operations
 import (
 	"errors"
 	"fmt"
+	"villain-couch/agent/src/library"
+	"villain-couch/agent/src/models"
 	"villain-couch/common/ff"
 	"villain-couch/common/logger"
-	"villain-couch/common/optional"
 )
 
 type NextEpisode struct {
@@ -27,15 +28,20 @@ func (a NextEpisode) Name() string {
 }
 
 func (a NextEpisode) Run() error {
-	file, err := a.Database.GetLatestUpdatedMediaFile()
-	if err != nil {
-		logger.Log.Error("Error getting latest updated media file", "error", err)
-		return err
-	}
+	// The next episode of the file about to be played, e.g. given with -file or picked with -continue.
+	current := a.Options.MediaFilePath
+	if current == "" {
+		file, err := a.Database.GetLatestUpdatedMediaFile()
+		if err != nil {
+			logger.Log.Error("Error getting latest updated media file", "error", err)
+			return err
+		}
 
-	if file == nil {
-		logger.Log.Error("no media file found")
-		return errors.New("no media file found")
+		if file == nil {
+			logger.Log.Error("no media file found")
+			return errors.New("no media file found")
+		}
+		current = file.Filepath
 	}
 
 	workspaces, err := a.Database.GetWorkspaces()
@@ -44,31 +50,34 @@ func (a NextEpisode) Run() error {
 		return err
 	}
 
-	ws := optional.FirstOrEmpty(workspaces)
-	if ws.IsEmpty() {
+	if len(workspaces) == 0 {
 		logger.Log.Error("no workspace found")
 		return errors.New("no workspace found")
 	}
 
-	relatedFiles, err := ff.FindRelatedFiles(ws.DirectoryPath, file.Filename)
+	info, err := ff.ParseEpisodeInfo(current)
 	if err != nil {
-		logger.Log.Error("Error finding related files", "error", err)
+		logger.Log.Error("Could not parse target filename to find next episode", "error", err)
 		return err
 	}
 
-	info, err := ff.ParseEpisodeInfo(file.Filename)
+	showKey := ff.CanonicalShowKey(info.ShowName)
+	var nextEpisode *models.LibraryFile
+	err = library.NewScanner(a.Database).Lookup(func() (bool, error) {
+		var err error
+		nextEpisode, err = a.Database.FindNextLibraryEpisode(showKey, info.Season, info.LastEpisode())
+		return nextEpisode != nil, err
+	})
 	if err != nil {
-		logger.Log.Error("Could not parse target filename to find next episode", "error", err)
 		return err
 	}
 
-	nextEpisode, found := ff.FindNextEpisode(info, relatedFiles)
-	if !found {
-		logger.Log.Warn("Could not find a subsequent episode in the related files.")
+	if nextEpisode == nil {
+		logger.Log.Warn("Could not find a subsequent episode in the library.")
 		logger.Log.Warn("You might be on the last available episode.")
-		return errors.New("could not find a subsequent episode in the related files")
+		return errors.New("could not find a subsequent episode in the library")
 	}
 
-	a.Options.FuzzyFoundNextEpisode = nextEpisode.FilePath
+	a.Options.FuzzyFoundNextEpisode = nextEpisode.Filepath
 	return nil
 }
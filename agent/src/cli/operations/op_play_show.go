@@ -0,0 +1,69 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"villain-couch/common/logger"
+	"villain-couch/common/str"
+)
+
+// PlayShow resolves a show name to the episode to watch next and plays it.
+type PlayShow struct {
+	Operation
+	Show string
+	// File is the -file flag, a show and a file do not go together.
+	File string
+}
+
+func (a PlayShow) Priority() int {
+	return OrderHigh
+}
+
+func (a PlayShow) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a PlayShow) Name() string {
+	return "Play Show"
+}
+
+func (a PlayShow) Run() error {
+	if a.File != "" {
+		logger.Log.Error("-show and -file cannot be used together")
+		return errors.New("-show and -file cannot be used together")
+	}
+
+	name := strings.TrimSpace(a.Show)
+	history, err := a.Database.GetMediaFiles()
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	candidates, err := findShows(a.Database, name, history)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+	show, err := pickShow(name, candidates)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	path, start, err := nextForShow(a.Database, show, history)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	a.Options.MediaFilePath = path
+	a.Options.MediaFileStartTime = strconv.Itoa(start)
+	fmt.Printf("Playing %s: %s from %s\n", show.name, filepath.Base(path), str.Clock(start))
+	return nil
+}
+
+func (a PlayShow) Finalize() {}
@@ -0,0 +1,40 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"villain-couch/common/logger"
+)
+
+type RemoveShowAlias struct {
+	Operation
+	Alias string
+}
+
+func (a RemoveShowAlias) Priority() int {
+	return OrderMedium
+}
+
+func (a RemoveShowAlias) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a RemoveShowAlias) Name() string {
+	return "Remove Show Alias"
+}
+
+func (a RemoveShowAlias) Run() error {
+	if err := a.Database.RemoveShowAlias(a.Alias); err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	fmt.Printf("Removed alias: %s\n", a.Alias)
+	return nil
+}
+
+func (a RemoveShowAlias) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
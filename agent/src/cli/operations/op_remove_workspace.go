@@ -0,0 +1,46 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"villain-couch/common/logger"
+)
+
+type RemoveWorkspace struct {
+	Operation
+	Workspace string // id, directory path or name
+}
+
+func (a RemoveWorkspace) Priority() int {
+	return OrderMedium
+}
+
+func (a RemoveWorkspace) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a RemoveWorkspace) Name() string {
+	return "Remove Workspace"
+}
+
+func (a RemoveWorkspace) Run() error {
+	ws, err := findWorkspace(a.Database, a.Workspace)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	if err := a.Database.RemoveWorkspace(ws.ID); err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	fmt.Printf("Removed workspace %d: %s (%s)\n", ws.ID, ws.DirectoryName, ws.DirectoryPath)
+	return nil
+}
+
+func (a RemoveWorkspace) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
@@ -0,0 +1,55 @@
+package operations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"villain-couch/common/logger"
+)
+
+type RenameWorkspace struct {
+	Operation
+	Workspace string // id, directory path or name
+	NewName   string
+}
+
+func (a RenameWorkspace) Priority() int {
+	return OrderMedium
+}
+
+func (a RenameWorkspace) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a RenameWorkspace) Name() string {
+	return "Rename Workspace"
+}
+
+func (a RenameWorkspace) Run() error {
+	name := strings.TrimSpace(a.NewName)
+	if name == "" {
+		logger.Log.Error("no new workspace name given, use -ws-name")
+		return errors.New("no new workspace name given")
+	}
+
+	ws, err := findWorkspace(a.Database, a.Workspace)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	if err := a.Database.RenameWorkspace(ws.ID, name); err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+
+	fmt.Printf("Renamed workspace %d: %s -> %s\n", ws.ID, ws.DirectoryName, name)
+	return nil
+}
+
+func (a RenameWorkspace) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
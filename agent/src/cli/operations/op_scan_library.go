@@ -0,0 +1,48 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"villain-couch/agent/src/library"
+	"villain-couch/common/logger"
+)
+
+type ScanLibrary struct {
+	Operation
+	// Full reads every directory again instead of skipping the unchanged ones.
+	Full bool
+}
+
+func (a ScanLibrary) Priority() int {
+	return OrderMedium
+}
+
+func (a ScanLibrary) DefaultError() string {
+	return fmt.Sprintf("Cannot run %s operation", a.Name())
+}
+
+func (a ScanLibrary) Name() string {
+	return "Scan Library"
+}
+
+func (a ScanLibrary) Run() error {
+	scanner := library.NewScanner(a.Database)
+	if a.Full {
+		scanner = scanner.Rescan()
+	}
+
+	stats, err := scanner.ScanAll()
+	fmt.Printf("Scanned %d directories, skipped %d unchanged, indexed %d files, removed %d directories.\n",
+		stats.ScannedDirs, stats.SkippedDirs, stats.Files, stats.RemovedDirs)
+	if err != nil {
+		logger.Log.Error(a.DefaultError(), "error", err)
+		return err
+	}
+	return nil
+}
+
+func (a ScanLibrary) Finalize() {
+	msg := fmt.Sprintf("COMMAND '%s' WILL NOW CLOSE THE AGENT.", a.Name())
+	logger.Log.Warn(msg)
+	os.Exit(0)
+}
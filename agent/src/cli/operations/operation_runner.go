@@ -42,10 +42,68 @@ func (opr *OperationRunner) Build(cliFlags *cli.CLIFlags, db *storage.DB, opts *
 		r := AddWorkspace{Operation: opBasics, DirPath: cliFlags.AddWorkspace.String(), DirName: dirName}
 		opr.Add(r)
 	}
+	if !cliFlags.RenameWS.Empty() {
+		r := RenameWorkspace{Operation: opBasics, Workspace: cliFlags.RenameWS.String(), NewName: cliFlags.WSName.String()}
+		opr.Add(r)
+	}
+	if !cliFlags.RemoveWS.Empty() {
+		r := RemoveWorkspace{Operation: opBasics, Workspace: cliFlags.RemoveWS.String()}
+		opr.Add(r)
+	}
+	if cliFlags.ListWS {
+		r := ListWorkspaces{Operation: opBasics}
+		opr.Add(r)
+	}
+	if !cliFlags.AliasAdd.Empty() {
+		r := AddShowAlias{Operation: opBasics, Alias: cliFlags.AliasAdd.String(), Canonical: cliFlags.AliasOf.String()}
+		opr.Add(r)
+	}
+	if !cliFlags.AliasRemove.Empty() {
+		r := RemoveShowAlias{Operation: opBasics, Alias: cliFlags.AliasRemove.String()}
+		opr.Add(r)
+	}
+	if cliFlags.AliasList {
+		r := ListShowAliases{Operation: opBasics}
+		opr.Add(r)
+	}
+	if cliFlags.Scan || cliFlags.Rescan {
+		r := ScanLibrary{Operation: opBasics, Full: cliFlags.Rescan}
+		opr.Add(r)
+	}
+	if !cliFlags.MarkWatched.Empty() {
+		r := MarkWatched{Operation: opBasics, Target: cliFlags.MarkWatched.String(), Season: cliFlags.MarkSeason, Watched: true}
+		opr.Add(r)
+	}
+	if !cliFlags.MarkUnwatch.Empty() {
+		r := MarkWatched{Operation: opBasics, Target: cliFlags.MarkUnwatch.String(), Season: cliFlags.MarkSeason, Watched: false}
+		opr.Add(r)
+	}
+	if cliFlags.Continue {
+		r := Continue{Operation: opBasics, File: cliFlags.MediaFile.String()}
+		opr.Add(r)
+	}
+	if !cliFlags.Show.Empty() {
+		r := PlayShow{Operation: opBasics, Show: cliFlags.Show.String(), File: cliFlags.MediaFile.String()}
+		opr.Add(r)
+	}
 	if cliFlags.FindNext {
 		r := NextEpisode{Operation: opBasics}
 		opr.Add(r)
 	}
+	if cliFlags.History {
+		r := History{
+			Operation: opBasics,
+			Show:      cliFlags.HistoryShow.String(),
+			Since:     cliFlags.HistorySince.String(),
+			Until:     cliFlags.HistoryUntil.String(),
+			Limit:     cliFlags.HistoryLimit,
+		}
+		opr.Add(r)
+	}
+	if !cliFlags.MatchDebug.Empty() {
+		r := MatchDebug{Operation: opBasics, Target: cliFlags.MatchDebug.String()}
+		opr.Add(r)
+	}
 	if cliFlags.Version {
 		r := PrintVersion{Version: app_info.VersionInfo}
 		opr.Add(r)
@@ -0,0 +1,136 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"villain-couch/agent/src/library"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/ff"
+	"villain-couch/common/fs"
+)
+
+// showCandidate is a show a name given on the command line may stand for.
+type showCandidate struct {
+	key   string // canonical show key
+	name  string
+	match ff.ShowMatch
+}
+
+// findShows matches a name against the shows in the library and the watch history, best match first.
+// A show is a candidate if it matches as a whole, or if it contains every word of the name, e.g. "office" for "The Office (US)".
+func findShows(db *storage.DB, name string, history []models.MediaFile) ([]showCandidate, error) {
+	shows, err := db.GetLibraryShows()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	for _, s := range shows {
+		names[s.ShowKey] = s.ShowName
+	}
+	for _, f := range history {
+		info, err := ff.ParseEpisodeInfo(f.Filepath)
+		if err != nil || info.ShowName == "" {
+			continue
+		}
+		key := ff.CanonicalShowKey(info.ShowName)
+		if _, ok := names[key]; !ok {
+			names[key] = info.ShowName
+		}
+	}
+
+	var candidates []showCandidate
+	for key, showName := range names {
+		m := ff.MatchShow(name, showName)
+		if m.Matched || (len(m.Tokens) > 0 && len(m.Missing) == 0) {
+			candidates = append(candidates, showCandidate{key: key, name: showName, match: m})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].match.Score != candidates[j].match.Score {
+			return candidates[i].match.Score > candidates[j].match.Score
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	return candidates, nil
+}
+
+// pickShow returns the show a name clearly stands for. If several shows are about as likely,
+// the error lists them, so the name can be made more specific.
+func pickShow(name string, candidates []showCandidate) (showCandidate, error) {
+	if len(candidates) == 0 {
+		return showCandidate{}, fmt.Errorf("no show found for '%s' in the library or the watch history", name)
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	best, second := candidates[0].match, candidates[1].match
+	if (best.Matched && !second.Matched) || (best.Score == 1 && second.Score < 1) {
+		return candidates[0], nil
+	}
+
+	var names []string
+	for _, c := range candidates {
+		names = append(names, fmt.Sprintf("%s (%.2f)", c.name, c.match.Score))
+	}
+	return showCandidate{}, fmt.Errorf("'%s' matches several shows, be more specific: %s", name, strings.Join(names, ", "))
+}
+
+// nextForShow returns the file to play for a show and the second to start at.
+// The file of the show that was played last is resumed if it is not finished,
+// otherwise the first unwatched episode after it in the library is played.
+// A file marked unwatched again has no position left, so it starts over.
+func nextForShow(db *storage.DB, show showCandidate, history []models.MediaFile) (string, int, error) {
+	var last *models.MediaFile
+	var lastInfo ff.EpisodeInfo
+	played := make(map[string]models.MediaFile)
+	for _, f := range history {
+		info, err := ff.ParseEpisodeInfo(f.Filepath)
+		if err != nil || ff.CanonicalShowKey(info.ShowName) != show.key {
+			continue
+		}
+		played[f.Filepath] = f
+		if last == nil {
+			last, lastInfo = &f, info
+		}
+	}
+
+	inProgress := last != nil && !last.Watched
+	if inProgress && fs.FileExists(last.Filepath) {
+		return last.Filepath, last.CurrentSecond, nil
+	}
+
+	var files []models.LibraryFile
+	err := library.NewScanner(db).Lookup(func() (bool, error) {
+		var err error
+		files, err = db.GetLibraryShowFiles(show.key, -1)
+		return len(files) > 0, err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	for _, f := range files {
+		if last != nil {
+			// An unfinished file that is gone, e.g. on a drive that is not plugged in, may have a copy elsewhere.
+			if inProgress && f.Season == lastInfo.Season && f.Episode == lastInfo.Episode && fs.FileExists(f.Filepath) {
+				return f.Filepath, last.CurrentSecond, nil
+			}
+			if f.Season < lastInfo.Season || (f.Season == lastInfo.Season && f.Episode <= lastInfo.LastEpisode()) {
+				continue
+			}
+		}
+		if p, ok := played[f.Filepath]; (ok && p.Watched) || !fs.FileExists(f.Filepath) {
+			continue
+		}
+		return f.Filepath, played[f.Filepath].CurrentSecond, nil
+	}
+
+	if last == nil {
+		return "", 0, fmt.Errorf("no episodes of '%s' found in the workspaces", show.name)
+	}
+	return "", 0, fmt.Errorf("no unwatched episode of '%s' left after %s", show.name, last.Filename)
+}
@@ -0,0 +1,92 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"villain-couch/agent/src/library"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlayShowResolvesNextEpisode(t *testing.T) {
+	db := storagetest.NewDB(t)
+	root := t.TempDir()
+	path := func(name string) string { return filepath.Join(root, filepath.FromSlash(name)) }
+	for _, name := range []string{
+		"The Office/The.Office.S01E01.mkv", "The Office/The.Office.S01E02.mkv", "The Office/The.Office.S02E01.mkv",
+		"The Office (US)/The.Office.US.S01E01.mkv", "Dark/Dark.S01E01.mkv", "Dark Matter/Dark.Matter.S01E01.mkv",
+		"Star Trek Discovery/Star.Trek.Discovery.S01E01.mkv", "Star Trek Picard/Star.Trek.Picard.S01E01.mkv",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path(name)), 0o755))
+		require.NoError(t, os.WriteFile(path(name), nil, 0o644))
+	}
+	now := time.Now()
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: root, DirectoryName: "tv", CreatedAt: now, UpdatedAt: now}))
+	_, err := library.NewScanner(db).ScanAll()
+	require.NoError(t, err)
+
+	resolve := func(name string) (string, int, error) {
+		history, err := db.GetMediaFiles()
+		require.NoError(t, err)
+		candidates, err := findShows(db, name, history)
+		require.NoError(t, err)
+		show, err := pickShow(name, candidates)
+		if err != nil {
+			return "", 0, err
+		}
+		return nextForShow(db, show, history)
+	}
+
+	// Never played: the first episode.
+	file, start, err := resolve("office")
+	require.NoError(t, err)
+	assert.Equal(t, path("The Office/The.Office.S01E01.mkv"), file)
+	assert.Equal(t, 0, start)
+
+	file, _, err = resolve("ofice us")
+	require.NoError(t, err)
+	assert.Equal(t, path("The Office (US)/The.Office.US.S01E01.mkv"), file)
+
+	file, _, err = resolve("dark")
+	require.NoError(t, err)
+	assert.Equal(t, path("Dark/Dark.S01E01.mkv"), file)
+
+	_, _, err = resolve("star trek")
+	assert.ErrorContains(t, err, "matches several shows")
+	_, _, err = resolve("friends")
+	assert.ErrorContains(t, err, "no show found")
+
+	// In progress: resume at the saved position.
+	require.NoError(t, db.SetMediaFile(models.MediaFile{Filepath: path("The Office/The.Office.S01E02.mkv"), Filename: "The.Office.S01E02.mkv", TotalSeconds: 1300, CurrentSecond: 400}))
+	file, start, err = resolve("the office")
+	require.NoError(t, err)
+	assert.Equal(t, path("The Office/The.Office.S01E02.mkv"), file)
+	assert.Equal(t, 400, start)
+
+	// Finished: the next episode, across seasons.
+	_, err = db.SetMediaFilesWatched([]string{path("The Office/The.Office.S01E02.mkv")}, true)
+	require.NoError(t, err)
+	file, start, err = resolve("the office")
+	require.NoError(t, err)
+	assert.Equal(t, path("The Office/The.Office.S02E01.mkv"), file)
+	assert.Equal(t, 0, start)
+
+	// Played to the credits, then marked unwatched: it starts over instead of resuming at the credits.
+	last := models.MediaFile{Filepath: path("The Office/The.Office.S02E01.mkv"), Filename: "The.Office.S02E01.mkv", TotalSeconds: 1300, CurrentSecond: 1280}
+	require.NoError(t, db.SetMediaFile(last))
+	require.NoError(t, db.CompleteMediaFile(last, time.Now()))
+	_, _, err = resolve("the office")
+	assert.ErrorContains(t, err, "no unwatched episode")
+
+	_, err = db.SetMediaFilesWatched([]string{last.Filepath}, false)
+	require.NoError(t, err)
+	file, start, err = resolve("the office")
+	require.NoError(t, err)
+	assert.Equal(t, last.Filepath, file)
+	assert.Equal(t, 0, start)
+}
@@ -0,0 +1,56 @@
+package operations
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+)
+
+// findWorkspace resolves a workspace from its id, directory path or name (case-insensitive).
+func findWorkspace(db *storage.DB, ref string) (models.Workspace, error) {
+	workspaces, err := db.GetWorkspaces()
+	if err != nil {
+		return models.Workspace{}, err
+	}
+
+	if id, err := strconv.Atoi(ref); err == nil {
+		for _, ws := range workspaces {
+			if ws.ID == id {
+				return ws, nil
+			}
+		}
+	}
+
+	path := filepath.Clean(ref)
+	if abs, err := filepath.Abs(ref); err == nil {
+		path = abs
+	}
+	for _, ws := range workspaces {
+		if filepath.Clean(ws.DirectoryPath) == path || filepath.Clean(ws.DirectoryPath) == filepath.Clean(ref) {
+			return ws, nil
+		}
+	}
+
+	var matches []models.Workspace
+	for _, ws := range workspaces {
+		if strings.EqualFold(ws.DirectoryName, ref) {
+			matches = append(matches, ws)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return models.Workspace{}, fmt.Errorf("no workspace found for '%s'", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		var ids []string
+		for _, ws := range matches {
+			ids = append(ids, fmt.Sprintf("%d (%s)", ws.ID, ws.DirectoryPath))
+		}
+		return models.Workspace{}, fmt.Errorf("'%s' matches several workspaces, use one of the ids: %s", ref, strings.Join(ids, ", "))
+	}
+}
@@ -0,0 +1,37 @@
+package operations
+
+import (
+	"path/filepath"
+	"testing"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindWorkspace(t *testing.T) {
+	db := storagetest.NewDB(t)
+	tv, movies, other := filepath.FromSlash("/media/tv"), filepath.FromSlash("/media/movies"), filepath.FromSlash("/nas/tv")
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: tv, DirectoryName: "tv"}))
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: movies, DirectoryName: "Movies"}))
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: other, DirectoryName: "tv"}))
+
+	ws, err := findWorkspace(db, "2")
+	require.NoError(t, err)
+	assert.Equal(t, movies, ws.DirectoryPath)
+
+	ws, err = findWorkspace(db, tv+string(filepath.Separator))
+	require.NoError(t, err)
+	assert.Equal(t, 1, ws.ID)
+
+	ws, err = findWorkspace(db, "movies")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ws.ID)
+
+	_, err = findWorkspace(db, "tv")
+	assert.ErrorContains(t, err, "several workspaces")
+
+	_, err = findWorkspace(db, "music")
+	assert.Error(t, err)
+}
@@ -1,9 +1,13 @@
 package cli
 
-import "os/exec"
+import (
+	"os/exec"
+	"slices"
+)
 
 type VLCRunnerArguments struct {
 	VLCPath      string
+	VLCArgs      []string
 	MediaFile    string
 	StartTime    string
 	ExtraIntf    string
@@ -11,9 +15,10 @@ type VLCRunnerArguments struct {
 	HttpPassword string
 }
 
-func PrepareRunnerArguments(VLCPath, MediaFile, StartTime, ExtraIntf, HttpPort, HttpPassword string) VLCRunnerArguments {
+func PrepareRunnerArguments(VLCPath string, VLCArgs []string, MediaFile, StartTime, ExtraIntf, HttpPort, HttpPassword string) VLCRunnerArguments {
 	return VLCRunnerArguments{
 		VLCPath:      VLCPath,
+		VLCArgs:      VLCArgs,
 		MediaFile:    MediaFile,
 		StartTime:    StartTime,
 		ExtraIntf:    ExtraIntf,
@@ -31,6 +36,7 @@ func PrepareVLCCommand(args VLCRunnerArguments) *exec.Cmd {
 		"--start-time", args.StartTime,
 	}
 
-	cmd := exec.Command(args.VLCPath, arr...)
+	// Launcher arguments (e.g. flatpak's `run org.videolan.VLC`) must come first.
+	cmd := exec.Command(args.VLCPath, append(slices.Clone(args.VLCArgs), arr...)...)
 	return cmd
 }
@@ -4,7 +4,13 @@ import (
 	_ "embed"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/ff"
 	"villain-couch/common/globals"
 	"villain-couch/common/logger"
 	"villain-couch/common/step"
@@ -13,6 +19,12 @@ import (
 //go:embed config.json
 var defaultSettings string
 
+// Supported media player backends for the `media_player` config key.
+const (
+	MediaPlayerVLC = "vlc"
+	MediaPlayerMPV = "mpv"
+)
+
 var appConfig *Config
 
 func GetConfig() *Config {
@@ -30,6 +42,81 @@ type Config struct {
 	DatabaseFileName string `json:"database_file_name"`
 	// For Linuxers
 	VLCPath string `json:"vlc_path"`
+	// Media player backend, either "vlc" or "mpv". Defaults to "vlc" when empty.
+	MediaPlayer  string `json:"media_player"`
+	MPVPath      string `json:"mpv_path"`
+	MPVIPCSocket string `json:"mpv_ipc_socket"`
+	// How often playback progress is written to the database. Defaults to 30 seconds.
+	CheckpointIntervalSeconds int `json:"checkpoint_interval_seconds"`
+	// How long the workspace watcher waits for changes to settle before rescanning. Defaults to 2 seconds.
+	WatchDebounceSeconds int `json:"watch_debounce_seconds"`
+	// Extra filename patterns with named groups (show, season, episode, absolute, date),
+	// tried in order before the built-in ones.
+	EpisodePatterns []string `json:"episode_patterns"`
+	// Score between 0 and 1 a directory needs to match a show name. Defaults to 0.8.
+	ShowMatchThreshold float64 `json:"show_match_threshold"`
+	// Percentage of a file that must be reached for a stop to count as finished. Defaults to 92.
+	CompletionPercent float64 `json:"completion_percent"`
+	// A stop with at most this many seconds left also counts as finished, e.g. to skip credits. Disabled when 0.
+	CompletionRemainingSeconds int `json:"completion_remaining_seconds"`
+	// Port of the local REST API, disabled when 0. Requests must carry APIToken.
+	APIPort  int    `json:"api_port"`
+	APIToken string `json:"api_token"`
+	// HTTP endpoints notified about playback events.
+	Webhooks []models.Webhook `json:"webhooks"`
+	// Executables run on playback events.
+	Hooks models.Hooks `json:"hooks"`
+}
+
+// GetMediaPlayer returns the configured media player backend, falling back to VLC.
+func (c *Config) GetMediaPlayer() string {
+	if c.MediaPlayer == "" {
+		return MediaPlayerVLC
+	}
+	return strings.ToLower(c.MediaPlayer)
+}
+
+// GetCheckpointInterval returns how often the cache is flushed to the database.
+func (c *Config) GetCheckpointInterval() time.Duration {
+	if c.CheckpointIntervalSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.CheckpointIntervalSeconds) * time.Second
+}
+
+// GetWatchDebounce returns how long the workspace watcher waits for a burst of changes to end.
+func (c *Config) GetWatchDebounce() time.Duration {
+	if c.WatchDebounceSeconds <= 0 {
+		return 2 * time.Second
+	}
+	return time.Duration(c.WatchDebounceSeconds) * time.Second
+}
+
+// GetCompletion returns when a stopped file counts as finished.
+// Without any threshold configured, models.DefaultCompletion is used.
+func (c *Config) GetCompletion() models.Completion {
+	if c.CompletionPercent <= 0 && c.CompletionRemainingSeconds <= 0 {
+		return models.DefaultCompletion
+	}
+	return models.Completion{Percent: c.CompletionPercent, RemainingSeconds: c.CompletionRemainingSeconds}
+}
+
+// GetAPIAddress returns the address the REST API listens on, or "" if it is disabled.
+// It only listens on localhost.
+func (c *Config) GetAPIAddress() string {
+	if c.APIPort <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("127.0.0.1:%d", c.APIPort)
+}
+
+// GetMPVIPCSocket returns the path of mpv's JSON IPC socket.
+// If it is not configured, a socket in the temp directory is used.
+func (c *Config) GetMPVIPCSocket() string {
+	if c.MPVIPCSocket == "" {
+		return filepath.Join(os.TempDir(), "villain_couch_mpv.sock")
+	}
+	return c.MPVIPCSocket
 }
 
 // setupConfig ensures the required configuration directory and the config file exist.
@@ -115,10 +202,83 @@ func loadConfig(...string) error {
 	return nil
 }
 
+func validateConfig(...string) error {
+	switch appConfig.GetMediaPlayer() {
+	case MediaPlayerVLC, MediaPlayerMPV:
+	default:
+		logger.Log.Error("unknown media player in config", "media_player", appConfig.MediaPlayer)
+		return fmt.Errorf("unknown media player '%s', expected '%s' or '%s'", appConfig.MediaPlayer, MediaPlayerVLC, MediaPlayerMPV)
+	}
+	if appConfig.ShowMatchThreshold < 0 || appConfig.ShowMatchThreshold > 1 {
+		logger.Log.Error("show match threshold must be between 0 and 1", "show_match_threshold", appConfig.ShowMatchThreshold)
+		return fmt.Errorf("invalid show_match_threshold %v, expected a value between 0 and 1", appConfig.ShowMatchThreshold)
+	}
+	if appConfig.CompletionPercent < 0 || appConfig.CompletionPercent > 100 {
+		logger.Log.Error("completion percent must be between 0 and 100", "completion_percent", appConfig.CompletionPercent)
+		return fmt.Errorf("invalid completion_percent %v, expected a value between 0 and 100", appConfig.CompletionPercent)
+	}
+	if appConfig.CompletionRemainingSeconds < 0 {
+		logger.Log.Error("completion remaining seconds must not be negative", "completion_remaining_seconds", appConfig.CompletionRemainingSeconds)
+		return fmt.Errorf("invalid completion_remaining_seconds %d, expected a positive number of seconds", appConfig.CompletionRemainingSeconds)
+	}
+	if appConfig.APIPort < 0 || appConfig.APIPort > 65535 {
+		logger.Log.Error("api port out of range", "api_port", appConfig.APIPort)
+		return fmt.Errorf("invalid api_port %d, expected a port between 1 and 65535, or 0 to disable the api", appConfig.APIPort)
+	}
+	if appConfig.APIPort > 0 && appConfig.APIToken == "" {
+		logger.Log.Error("api port is set without a token", "api_port", appConfig.APIPort)
+		return errors.New("api_token must be set to enable the api")
+	}
+	for i, w := range appConfig.Webhooks {
+		if err := w.Validate(); err != nil {
+			logger.Log.Error("invalid webhook in config", "index", i, "url", w.URL, "error", err)
+			return fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+	}
+	if err := appConfig.Hooks.Validate(); err != nil {
+		logger.Log.Error("invalid hook in config", "error", err)
+		return fmt.Errorf("hooks: %w", err)
+	}
+	return nil
+}
+
+// loadEpisodePatterns compiles the custom episode patterns and hands them to ff.
+// Every invalid pattern is reported, not only the first one.
+func loadEpisodePatterns(...string) error {
+	var patterns []*ff.Pattern
+	var errs []error
+	for i, expr := range appConfig.EpisodePatterns {
+		p, err := ff.CompilePattern(expr)
+		if err != nil {
+			logger.Log.Error("invalid episode pattern in config", "index", i, "pattern", expr, "error", err)
+			errs = append(errs, fmt.Errorf("episode_patterns[%d] %q: %w", i, expr, err))
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	ff.SetCustomPatterns(patterns)
+	return nil
+}
+
+// setupShowMatching hands the show match threshold to ff, the default is kept when it is not set.
+func setupShowMatching(...string) error {
+	if appConfig.ShowMatchThreshold > 0 {
+		ff.SetMatchThreshold(appConfig.ShowMatchThreshold)
+	}
+	return nil
+}
+
 func Initialize() error {
 	steps := []step.Step{
 		{F: setupConfig},
 		{F: loadConfig},
+		{F: validateConfig},
+		{F: loadEpisodePatterns},
+		{F: setupShowMatching},
 	}
 	return step.RunSteps(steps)
 }
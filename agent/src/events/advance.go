@@ -0,0 +1,63 @@
+package events
+
+import (
+	"errors"
+	"time"
+	mediaplayer "villain-couch/agent/src/media-player"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/options"
+	"villain-couch/common/logger"
+	re "villain-couch/common/regex"
+)
+
+// AutoAdvance plays the next episode once a file is completed and publishes an advanced event.
+// A file stopped before the end keeps its position and nothing else is played.
+// When there is no next episode, the media player is closed.
+type AutoAdvance struct {
+	player mediaplayer.MediaPlayer
+	opts   *options.Options
+	bus    *Bus
+}
+
+func NewAutoAdvance(player mediaplayer.MediaPlayer, opts *options.Options, bus *Bus) *AutoAdvance {
+	return &AutoAdvance{player: player, opts: opts, bus: bus}
+}
+
+// Handle is the subscriber of the bus.
+func (a *AutoAdvance) Handle(e models.PlaybackEvent) {
+	if e.Type != models.EventCompleted {
+		return
+	}
+
+	next, err := a.playNext(e.Filepath)
+	if err != nil {
+		logger.Log.Warn("cannot play next file", "error", err)
+		_ = a.player.Runner().Stop()
+		return
+	}
+
+	advanced := e
+	advanced.Type = models.EventAdvanced
+	advanced.Next = next
+	advanced.At = time.Now()
+	a.bus.Publish(advanced)
+}
+
+// playNext plays the episode after current in its directory, or the one found with --find-next.
+func (a *AutoAdvance) playNext(current string) (string, error) {
+	err := a.player.TryNext(current)
+	if err == nil {
+		next, _ := re.GetNextEpisodeFilename(current)
+		return next, nil
+	}
+
+	// in macOS we need to handle this as well
+	if !errors.Is(err, mediaplayer.ErrorMediaFileNotFound) || a.opts.FuzzyFoundNextEpisode == "" {
+		return "", err
+	}
+	if err := a.player.PlayFile(a.opts.FuzzyFoundNextEpisode); err != nil {
+		logger.Log.Error("Media Player PlayFile Error on Fuzzy Found Next Episode", "error", err, "path", a.opts.FuzzyFoundNextEpisode)
+		return "", err
+	}
+	return a.opts.FuzzyFoundNextEpisode, nil
+}
@@ -0,0 +1,79 @@
+// Package events turns what the agent sees on every tick into typed playback events
+// and hands them to independent subscribers, e.g. persistence, auto-advance and logging.
+package events
+
+import (
+	"sync"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+)
+
+// Handler receives the events of a subscription.
+type Handler func(e models.PlaybackEvent)
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Bus delivers every published event to every subscriber, in the order they subscribed.
+//
+// Delivery is synchronous, so subscribers run one after another in the goroutine that publishes,
+// which is the tick loop for the media player. A subscriber doing slow work must hand it off itself.
+// Events published by a subscriber are queued and delivered once the current event reached
+// every subscriber, so all subscribers see the events in the same order.
+type Bus struct {
+	mu          sync.Mutex
+	subscribers []subscriber
+	queue       []models.PlaybackEvent
+	delivering  bool
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe adds a handler that receives every event published from now on.
+// The name identifies the subscriber in the logs.
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber{name: name, handler: handler})
+}
+
+// Publish delivers events to the subscribers.
+func (b *Bus) Publish(events ...models.PlaybackEvent) {
+	b.mu.Lock()
+	b.queue = append(b.queue, events...)
+	if b.delivering {
+		// The outermost Publish delivers the queue.
+		b.mu.Unlock()
+		return
+	}
+	b.delivering = true
+
+	for len(b.queue) > 0 {
+		e := b.queue[0]
+		b.queue = b.queue[1:]
+		subscribers := b.subscribers
+		b.mu.Unlock()
+
+		for _, s := range subscribers {
+			deliver(s, e)
+		}
+
+		b.mu.Lock()
+	}
+	b.delivering = false
+	b.mu.Unlock()
+}
+
+// deliver runs a single handler, a panicking subscriber must not take the others down.
+func deliver(s subscriber, e models.PlaybackEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Log.Error("event subscriber panicked", "subscriber", s.name, "type", e.Type, "panic", r)
+		}
+	}()
+	s.handler(e)
+}
@@ -0,0 +1,35 @@
+package events
+
+import (
+	"testing"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusDeliversInOrder(t *testing.T) {
+	logger.Initialize(false)
+	bus := NewBus()
+
+	var got []string
+	record := func(name string) Handler {
+		return func(e models.PlaybackEvent) {
+			got = append(got, name+":"+e.Type)
+		}
+	}
+
+	bus.Subscribe("first", record("first"))
+	// A subscriber publishing an event does not overtake the event it handles.
+	bus.Subscribe("advance", func(e models.PlaybackEvent) {
+		if e.Type == models.EventCompleted {
+			bus.Publish(models.PlaybackEvent{Type: models.EventAdvanced})
+		}
+	})
+	bus.Subscribe("broken", func(models.PlaybackEvent) { panic("boom") })
+	bus.Subscribe("last", record("last"))
+
+	bus.Publish(models.PlaybackEvent{Type: models.EventCompleted})
+
+	assert.Equal(t, []string{"first:completed", "last:completed", "first:advanced", "last:advanced"}, got)
+}
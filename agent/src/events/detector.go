@@ -0,0 +1,131 @@
+package events
+
+import (
+	"time"
+	"villain-couch/agent/src/models"
+)
+
+// seekTolerance is how many seconds the position may drift from the expected one before it counts as a seek.
+const seekTolerance = 5
+
+// DefaultProgressInterval is how often a progress event is sent while playing.
+const DefaultProgressInterval = 5 * time.Second
+
+// snapshot is what the agent saw on a single tick.
+type snapshot struct {
+	Filepath string
+	Filename string
+	State    string
+	Time     int
+	Length   int
+	At       time.Time
+}
+
+// Detector derives playback events from successive status snapshots.
+// It is not safe for concurrent use, it is fed by the tick loop.
+type Detector struct {
+	completion       models.Completion
+	progressInterval time.Duration
+
+	prev         snapshot // the previous tick
+	playing      snapshot // the last tick with a file playing or paused
+	lastProgress time.Time
+}
+
+// NewDetector creates a detector that tells finished files apart from stopped ones with completion.
+func NewDetector(completion models.Completion) *Detector {
+	return &Detector{completion: completion, progressInterval: DefaultProgressInterval}
+}
+
+// Observe compares a tick with the previous one and returns what happened in between,
+// followed by an EventTick with the current position while a file is playing or paused.
+func (d *Detector) Observe(status models.StatusMessage, filepath string, at time.Time) []models.PlaybackEvent {
+	cur := snapshot{
+		Filepath: filepath,
+		Filename: status.GetFilename(),
+		State:    status.GetState(),
+		Time:     status.GetTime(),
+		Length:   status.GetLength(),
+		At:       at,
+	}
+	events := d.detect(d.prev, cur)
+
+	d.prev = cur
+	if cur.State != models.StateStopped && cur.Filepath != "" {
+		d.playing = cur
+		events = append(events, newEvent(models.EventTick, cur, at))
+	}
+	return events
+}
+
+// Exit returns the event for the media player being closed, with the last file that played.
+func (d *Detector) Exit(at time.Time) models.PlaybackEvent {
+	e := newEvent(models.EventExited, d.playing, at)
+	e.State = models.StateStopped
+	return e
+}
+
+func (d *Detector) detect(prev, cur snapshot) []models.PlaybackEvent {
+	if cur.State == models.StateStopped || cur.Filepath == "" {
+		return d.detectStop(prev, cur.At)
+	}
+
+	var events []models.PlaybackEvent
+	switch {
+	case prev.Filepath != cur.Filepath || prev.State == models.StateStopped || prev.State == "":
+		events = append(events, newEvent(models.EventStarted, cur, cur.At))
+		d.lastProgress = cur.At
+	case prev.State == models.StatePlaying && cur.State == models.StatePaused:
+		events = append(events, newEvent(models.EventPaused, cur, cur.At))
+	case prev.State == models.StatePaused && cur.State == models.StatePlaying:
+		events = append(events, newEvent(models.EventResumed, cur, cur.At))
+	case hasSeeked(prev, cur):
+		e := newEvent(models.EventSeeked, cur, cur.At)
+		e.From = prev.Time
+		events = append(events, e)
+	}
+
+	if cur.State == models.StatePlaying && cur.At.Sub(d.lastProgress) >= d.progressInterval {
+		events = append(events, newEvent(models.EventProgress, cur, cur.At))
+		d.lastProgress = cur.At
+	}
+	return events
+}
+
+// detectStop reports a file that stopped since the previous tick.
+// The player resets the position once it stops, so the last position seen while playing decides
+// whether the file was finished.
+func (d *Detector) detectStop(prev snapshot, at time.Time) []models.PlaybackEvent {
+	if prev.State != models.StatePlaying && prev.State != models.StatePaused {
+		return nil
+	}
+
+	last := d.playing
+	eventType := models.EventStopped
+	if d.completion.IsFinished(last.Time, last.Length) {
+		eventType = models.EventCompleted
+	}
+	e := newEvent(eventType, last, at)
+	e.State = models.StateStopped
+	return []models.PlaybackEvent{e}
+}
+
+// hasSeeked reports whether the position moved more than the elapsed time explains.
+func hasSeeked(prev, cur snapshot) bool {
+	if prev.At.IsZero() || prev.Filepath != cur.Filepath {
+		return false
+	}
+
+	expected := prev.Time
+	if prev.State == models.StatePlaying {
+		expected += int(cur.At.Sub(prev.At).Seconds())
+	}
+	drift := cur.Time - expected
+	return drift > seekTolerance || drift < -seekTolerance
+}
+
+func newEvent(eventType string, s snapshot, at time.Time) models.PlaybackEvent {
+	e := models.NewPlaybackEvent(eventType, s.Filepath, s.Filename, s.Time, s.Length, at)
+	e.State = s.State
+	return e
+}
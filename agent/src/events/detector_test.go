@@ -0,0 +1,129 @@
+package events
+
+import (
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasSeeked(t *testing.T) {
+	start := time.Now()
+	playing := snapshot{Filepath: "/media/a.mkv", State: models.StatePlaying, Time: 100, At: start}
+
+	tests := []struct {
+		name     string
+		prev     snapshot
+		cur      snapshot
+		expected bool
+	}{
+		{"first tick", snapshot{}, playing, false},
+		{"normal playback", playing, snapshot{Filepath: "/media/a.mkv", State: models.StatePlaying, Time: 101, At: start.Add(time.Second)}, false},
+		{"paused", playing, snapshot{Filepath: "/media/a.mkv", State: models.StatePaused, Time: 100, At: start.Add(time.Second)}, false},
+		{"seek forward", playing, snapshot{Filepath: "/media/a.mkv", State: models.StatePlaying, Time: 400, At: start.Add(time.Second)}, true},
+		{"seek backward", playing, snapshot{Filepath: "/media/a.mkv", State: models.StatePlaying, Time: 10, At: start.Add(time.Second)}, true},
+		{"file change", playing, snapshot{Filepath: "/media/b.mkv", State: models.StatePlaying, Time: 1, At: start.Add(time.Second)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hasSeeked(tt.prev, tt.cur))
+		})
+	}
+}
+
+func TestDetectorEvents(t *testing.T) {
+	start := time.Now()
+	tick := func(path, state string, second int, after time.Duration) snapshot {
+		return snapshot{Filepath: path, State: state, Time: second, Length: 100, At: start.Add(after)}
+	}
+	playing := tick("/media/a.mkv", models.StatePlaying, 10, 0)
+
+	tests := []struct {
+		name     string
+		prev     snapshot
+		cur      snapshot
+		expected []string
+	}{
+		{"first tick", snapshot{}, playing, []string{models.EventStarted}},
+		{"started after stop", tick("/media/a.mkv", models.StateStopped, 0, 0), tick("/media/a.mkv", models.StatePlaying, 0, time.Second), []string{models.EventStarted}},
+		{"file change", playing, tick("/media/b.mkv", models.StatePlaying, 0, time.Second), []string{models.EventStarted}},
+		{"normal playback", playing, tick("/media/a.mkv", models.StatePlaying, 10, 0), nil},
+		{"paused", playing, tick("/media/a.mkv", models.StatePaused, 10, 0), []string{models.EventPaused}},
+		{"resumed", tick("/media/a.mkv", models.StatePaused, 10, 0), tick("/media/a.mkv", models.StatePlaying, 10, 0), []string{models.EventResumed}},
+		{"seeked", playing, tick("/media/a.mkv", models.StatePlaying, 60, 0), []string{models.EventSeeked}},
+		{"no progress within interval", playing, tick("/media/a.mkv", models.StatePlaying, 11, time.Second), nil},
+		{"progress", playing, tick("/media/a.mkv", models.StatePlaying, 15, 5*time.Second), []string{models.EventProgress}},
+		{"no progress while paused", tick("/media/a.mkv", models.StatePaused, 10, 0), tick("/media/a.mkv", models.StatePaused, 10, 2*time.Second), nil},
+		{"stopped", playing, tick("/media/a.mkv", models.StateStopped, 0, time.Second), []string{models.EventStopped}},
+		{"still stopped", tick("/media/a.mkv", models.StateStopped, 0, 0), tick("/media/a.mkv", models.StateStopped, 0, time.Second), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(models.DefaultCompletion)
+			d.playing, d.lastProgress = tt.prev, start
+			assert.Equal(t, tt.expected, eventTypes(d.detect(tt.prev, tt.cur)))
+		})
+	}
+}
+
+func eventTypes(events []models.PlaybackEvent) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// status is a StatusMessage with the fields the detector reads.
+type status struct {
+	state        string
+	time, length int
+	filename     string
+}
+
+func (s status) GetShowName() string      { return "" }
+func (s status) GetTitle() string         { return "" }
+func (s status) GetEpisodeNumber() string { return "" }
+func (s status) GetSeasonNumber() string  { return "" }
+func (s status) GetState() string         { return s.state }
+func (s status) GetTime() int             { return s.time }
+func (s status) GetLength() int           { return s.length }
+func (s status) GetFilename() string      { return s.filename }
+
+func TestDetectorCompletesWithLastPosition(t *testing.T) {
+	start := time.Now()
+	d := NewDetector(models.Completion{RemainingSeconds: 30})
+
+	events := d.Observe(status{models.StatePlaying, 50, 100, "a.mkv"}, "/media/a.mkv", start)
+	assert.Equal(t, []string{models.EventStarted, models.EventTick}, eventTypes(events))
+	events = d.Observe(status{models.StatePlaying, 51, 100, "a.mkv"}, "/media/a.mkv", start.Add(time.Second))
+	assert.Equal(t, []string{models.EventTick}, eventTypes(events), "every tick, progress only every few seconds")
+	assert.Equal(t, 51, events[0].Position)
+	events = d.Observe(status{models.StatePlaying, 70, 100, "a.mkv"}, "/media/a.mkv", start.Add(20*time.Second))
+	assert.Equal(t, []string{models.EventProgress, models.EventTick}, eventTypes(events))
+
+	// The player resets the position once it stops.
+	events = d.Observe(status{models.StateStopped, 0, 0, ""}, "/media/a.mkv", start.Add(21*time.Second))
+	require.Len(t, events, 1)
+	assert.Equal(t, models.EventCompleted, events[0].Type)
+	assert.Equal(t, models.StateStopped, events[0].State)
+	assert.Equal(t, 70, events[0].Position)
+	assert.Equal(t, 100, events[0].Length)
+	assert.Equal(t, "a.mkv", events[0].Filename)
+
+	exited := d.Exit(start.Add(time.Minute))
+	assert.Equal(t, models.EventExited, exited.Type)
+	assert.Equal(t, "/media/a.mkv", exited.Filepath)
+
+	events = d.Observe(status{models.StatePlaying, 70, 100, "a.mkv"}, "/media/a.mkv", start.Add(22*time.Second))
+	assert.Equal(t, []string{models.EventStarted, models.EventTick}, eventTypes(events))
+	seek := d.Observe(status{models.StatePlaying, 10, 100, "a.mkv"}, "/media/a.mkv", start.Add(22*time.Second+500*time.Millisecond))
+	require.Equal(t, []string{models.EventSeeked, models.EventTick}, eventTypes(seek))
+	assert.Equal(t, models.EventSeeked, seek[0].Type)
+	assert.Equal(t, 70, seek[0].From)
+	assert.Equal(t, 10.0, seek[0].Progress)
+}
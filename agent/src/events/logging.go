@@ -0,0 +1,23 @@
+package events
+
+import (
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+	"villain-couch/common/str"
+)
+
+// Log is the subscriber writing the events to the log.
+func Log(e models.PlaybackEvent) {
+	switch e.Type {
+	case models.EventProgress:
+		// Logged with every tick already.
+	case models.EventTick:
+		logger.Log.Info("Pinged", "Filename", e.Filename, "State", e.State, "Time", str.Clock(e.Position), "Total Time", str.Clock(e.Length))
+	case models.EventSeeked:
+		logger.Log.Info("Playback seeked", "Filename", e.Filename, "From", str.Clock(e.From), "Time", str.Clock(e.Position))
+	case models.EventAdvanced:
+		logger.Log.Info("Playback advanced", "Filename", e.Filename, "Next", e.Next)
+	default:
+		logger.Log.Info("Playback "+e.Type, "Filename", e.Filename, "Time", str.Clock(e.Position), "Total Time", str.Clock(e.Length))
+	}
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/logger"
+)
+
+// Persistence keeps the playback position of the files in the cache and saves it to the database.
+// The flusher saves the cache periodically, pause, seek and file changes are saved right away.
+type Persistence struct {
+	cache   *storage.Cache[models.MediaFile]
+	flusher *storage.Flusher
+}
+
+func NewPersistence(cache *storage.Cache[models.MediaFile], flusher *storage.Flusher) *Persistence {
+	return &Persistence{cache: cache, flusher: flusher}
+}
+
+// Handle is the subscriber of the bus.
+func (p *Persistence) Handle(e models.PlaybackEvent) {
+	switch e.Type {
+	case models.EventTick:
+		p.set(e)
+	case models.EventStarted, models.EventPaused, models.EventResumed, models.EventSeeked:
+		// Checkpoint right away instead of waiting for the next interval.
+		p.set(e)
+		p.flusher.Trigger()
+	case models.EventStopped:
+		p.set(e)
+		p.flush()
+	case models.EventCompleted:
+		p.set(e)
+		p.flush()
+		// The file is finished, there is nothing left to save for it.
+		p.cache.Delete(e.Filepath)
+	case models.EventExited:
+		p.flush()
+	}
+}
+
+func (p *Persistence) set(e models.PlaybackEvent) {
+	if e.Filepath == "" {
+		return
+	}
+	mf := models.MediaFile{
+		Filepath:      e.Filepath,
+		Filename:      e.Filename,
+		TotalSeconds:  e.Length,
+		CurrentSecond: e.Position,
+		CreatedAt:     e.At,
+		UpdatedAt:     e.At,
+	}
+	p.cache.Set(mf.Filepath, mf)
+}
+
+func (p *Persistence) flush() {
+	logger.Log.Info("Saving media states...")
+	if err := p.flusher.Flush(); err != nil {
+		logger.Log.Error("could not save state to database", "error", err.Error())
+	}
+}
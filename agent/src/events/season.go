@@ -0,0 +1,64 @@
+package events
+
+import (
+	"os"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/ff"
+	"villain-couch/common/logger"
+	re "villain-couch/common/regex"
+)
+
+// SeasonCompletion publishes a season_completed event when the completed file is the last
+// available episode of its season, neither its directory nor the library index has a later one.
+type SeasonCompletion struct {
+	db  *storage.DB
+	bus *Bus
+}
+
+func NewSeasonCompletion(db *storage.DB, bus *Bus) *SeasonCompletion {
+	return &SeasonCompletion{db: db, bus: bus}
+}
+
+// Handle is the subscriber of the bus.
+func (s *SeasonCompletion) Handle(e models.PlaybackEvent) {
+	if e.Type != models.EventCompleted {
+		return
+	}
+
+	info, err := ff.ParseEpisodeInfo(e.Filepath)
+	// Shows numbered by absolute episode or air date have no seasons to complete.
+	if err != nil || info.Season == 0 || !info.AirDate.IsZero() {
+		return
+	}
+	if s.hasLaterEpisode(e.Filepath, info) {
+		return
+	}
+
+	season := e
+	season.Type = models.EventSeasonCompleted
+	season.Show = info.ShowName
+	season.Season = info.Season
+	season.Episode = info.LastEpisode()
+	season.At = time.Now()
+	s.bus.Publish(season)
+}
+
+func (s *SeasonCompletion) hasLaterEpisode(filepath string, info ff.EpisodeInfo) bool {
+	if next, ok := re.GetNextEpisodeFilename(filepath); ok {
+		if _, err := os.Stat(next); err == nil {
+			if nextInfo, err := ff.ParseEpisodeInfo(next); err == nil && nextInfo.Season == info.Season {
+				return true
+			}
+		}
+	}
+
+	next, err := s.db.FindNextLibraryEpisode(ff.CanonicalShowKey(info.ShowName), info.Season, info.LastEpisode())
+	if err != nil {
+		logger.Log.Warn("could not look up the next episode in the library", "path", filepath, "error", err)
+		// Rather miss a season event than send one too early.
+		return true
+	}
+	return next != nil && next.Season == info.Season
+}
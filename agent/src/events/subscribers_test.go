@@ -0,0 +1,107 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	mediaplayer "villain-couch/agent/src/media-player"
+	"villain-couch/agent/src/media-player/fakevlc"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/options"
+	"villain-couch/agent/src/storage"
+	"villain-couch/agent/src/storage/storagetest"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistence(t *testing.T) {
+	db := storagetest.NewDB(t)
+	cache := storage.NewCache[models.MediaFile]()
+	p := NewPersistence(cache, storage.NewFlusher(db, cache))
+	at := time.Now()
+
+	p.Handle(models.NewPlaybackEvent(models.EventTick, "/media/a.mkv", "a.mkv", 40, 100, at))
+	cached, found := cache.Get("/media/a.mkv")
+	require.True(t, found)
+	assert.Equal(t, 40, cached.CurrentSecond)
+
+	// A stop saves the position right away and keeps it cached.
+	p.Handle(models.NewPlaybackEvent(models.EventStopped, "/media/a.mkv", "a.mkv", 50, 100, at))
+	saved, err := db.GetMediaFile("/media/a.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, 50, saved.CurrentSecond)
+	_, found = cache.Get("/media/a.mkv")
+	assert.True(t, found)
+
+	// A completed file is saved at its last position and dropped from the cache.
+	p.Handle(models.NewPlaybackEvent(models.EventCompleted, "/media/a.mkv", "a.mkv", 98, 100, at))
+	saved, err = db.GetMediaFile("/media/a.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, 98, saved.CurrentSecond)
+	_, found = cache.Get("/media/a.mkv")
+	assert.False(t, found)
+}
+
+func TestAutoAdvance(t *testing.T) {
+	logger.Initialize(false)
+	fake := fakevlc.New("secret")
+	t.Cleanup(fake.Close)
+	opts := &options.Options{}
+	player := mediaplayer.NewVLC(fake.Config(), opts)
+
+	dir := t.TempDir()
+	current, next := filepath.Join(dir, "Show.S01E01.mkv"), filepath.Join(dir, "Show.S01E02.mkv")
+	require.NoError(t, os.WriteFile(current, nil, 0644))
+	require.NoError(t, os.WriteFile(next, nil, 0644))
+
+	bus := NewBus()
+	var published []models.PlaybackEvent
+	bus.Subscribe("auto-advance", NewAutoAdvance(player, opts, bus).Handle)
+	bus.Subscribe("recorder", func(e models.PlaybackEvent) { published = append(published, e) })
+
+	// A file stopped before the end stays where it is.
+	fake.Load(current, 100)
+	bus.Publish(models.NewPlaybackEvent(models.EventStopped, current, "Show.S01E01.mkv", 50, 100, time.Now()))
+	assert.Equal(t, current, fake.Current())
+
+	bus.Publish(models.NewPlaybackEvent(models.EventCompleted, current, "Show.S01E01.mkv", 99, 100, time.Now()))
+	assert.Equal(t, next, fake.Current())
+	require.Len(t, published, 3)
+	assert.Equal(t, models.EventAdvanced, published[2].Type)
+	assert.Equal(t, current, published[2].Filepath)
+	assert.Equal(t, next, published[2].Next)
+}
+
+func TestSeasonCompletion(t *testing.T) {
+	db := storagetest.NewDB(t)
+
+	dir := t.TempDir()
+	first, last := filepath.Join(dir, "Show.S01E01.mkv"), filepath.Join(dir, "Show.S01E02.mkv")
+	require.NoError(t, os.WriteFile(first, nil, 0644))
+	require.NoError(t, os.WriteFile(last, nil, 0644))
+
+	bus := NewBus()
+	var published []models.PlaybackEvent
+	bus.Subscribe("season", NewSeasonCompletion(db, bus).Handle)
+	bus.Subscribe("recorder", func(e models.PlaybackEvent) { published = append(published, e) })
+
+	// The next episode of the season is on disk.
+	bus.Publish(models.NewPlaybackEvent(models.EventCompleted, first, "Show.S01E01.mkv", 99, 100, time.Now()))
+	require.Len(t, published, 1)
+
+	// A stopped episode does not complete the season.
+	bus.Publish(models.NewPlaybackEvent(models.EventStopped, last, "Show.S01E02.mkv", 50, 100, time.Now()))
+	require.Len(t, published, 2)
+
+	bus.Publish(models.NewPlaybackEvent(models.EventCompleted, last, "Show.S01E02.mkv", 99, 100, time.Now()))
+	require.Len(t, published, 4)
+	season := published[3]
+	assert.Equal(t, models.EventSeasonCompleted, season.Type)
+	assert.Equal(t, last, season.Filepath)
+	assert.Equal(t, 1, season.Season)
+	assert.Equal(t, 2, season.Episode)
+	assert.NotEmpty(t, season.Show)
+}
@@ -0,0 +1,112 @@
+package history
+
+import (
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/logger"
+)
+
+// seekTolerance is how many seconds the position may move beyond the elapsed wall-clock time
+// and still count as watched. Larger jumps are seeks and are not counted.
+const seekTolerance = 3
+
+// Tracker turns the agent's ticks into watch sessions and records them in the database.
+// A completed session marks its file watched and counts as a play.
+type Tracker struct {
+	db         *storage.DB
+	completion models.Completion
+	current    *models.WatchSession
+
+	lastState string
+	lastTime  int
+	lastAt    time.Time
+}
+
+// NewTracker creates a tracker that marks sessions reaching the completion threshold as completed.
+func NewTracker(db *storage.DB, completion models.Completion) *Tracker {
+	return &Tracker{db: db, completion: completion}
+}
+
+// Observe feeds a single tick into the tracker.
+// A session ends when the file changes or playback stops.
+func (t *Tracker) Observe(filepath, filename, state string, position, length int, at time.Time) {
+	if state == models.StateStopped || filepath == "" {
+		t.end()
+		return
+	}
+
+	if t.current != nil && t.current.Filepath != filepath {
+		t.end()
+	}
+
+	if t.current == nil {
+		t.current = &models.WatchSession{
+			Filepath:    filepath,
+			Filename:    filename,
+			StartedAt:   at,
+			StartSecond: position,
+		}
+	} else if t.lastState == models.StatePlaying {
+		delta := position - t.lastTime
+		elapsed := int(at.Sub(t.lastAt).Seconds())
+		if delta > 0 && delta <= elapsed+seekTolerance {
+			t.current.WatchedSeconds += delta
+		}
+	}
+
+	t.current.EndedAt = at
+	t.current.EndSecond = position
+	if length > 0 {
+		t.current.TotalSeconds = length
+	}
+	if filename != "" {
+		t.current.Filename = filename
+	}
+
+	t.lastState = state
+	t.lastTime = position
+	t.lastAt = at
+}
+
+// Handle feeds a playback event into the tracker, it is the subscriber of the event bus.
+func (t *Tracker) Handle(e models.PlaybackEvent) {
+	if e.State == models.StateStopped && t.current != nil && t.current.Filepath == e.Filepath {
+		// Stop events carry the last position seen while playing, count it before the session ends.
+		t.Observe(e.Filepath, e.Filename, t.lastState, e.Position, e.Length, e.At)
+	}
+	t.Observe(e.Filepath, e.Filename, e.State, e.Position, e.Length, e.At)
+}
+
+// Close ends the open session, e.g. when the agent exits.
+func (t *Tracker) Close() {
+	t.end()
+}
+
+func (t *Tracker) end() {
+	session := t.current
+	t.current = nil
+	t.lastState = ""
+
+	if session == nil {
+		return
+	}
+
+	session.Completed = t.completion.IsFinished(session.EndSecond, session.TotalSeconds)
+
+	// Opening a file without watching it is not worth a history entry.
+	if session.WatchedSeconds == 0 && !session.Completed {
+		return
+	}
+
+	if err := t.db.InsertWatchSession(*session); err != nil {
+		logger.Log.Error("could not record watch session", "error", err)
+	}
+
+	if session.Completed {
+		mf := models.MediaFile{Filepath: session.Filepath, Filename: session.Filename, TotalSeconds: session.TotalSeconds}
+		if err := t.db.CompleteMediaFile(mf, session.EndedAt); err != nil {
+			logger.Log.Error("could not mark media file watched", "error", err)
+		}
+	}
+}
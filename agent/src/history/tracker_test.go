@@ -0,0 +1,111 @@
+package history
+
+import (
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracker(t *testing.T) (*Tracker, *storage.DB) {
+	db := storagetest.NewDB(t)
+	return NewTracker(db, models.DefaultCompletion), db
+}
+
+func allSessions(t *testing.T, db *storage.DB) []models.WatchSession {
+	sessions, err := db.GetWatchSessions(models.WatchSessionFilter{})
+	require.NoError(t, err)
+	return sessions
+}
+
+func TestTrackerRecordsCompletedSession(t *testing.T) {
+	tracker, db := newTestTracker(t)
+	start := time.Now()
+
+	for i := 0; i <= 100; i++ {
+		tracker.Observe("/media/a.mkv", "a.mkv", models.StatePlaying, 10+i, 112, start.Add(time.Duration(i)*time.Second))
+	}
+	// VLC resets time and length once it stops.
+	tracker.Observe("/media/a.mkv", "", models.StateStopped, 0, 0, start.Add(101*time.Second))
+
+	sessions := allSessions(t, db)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, 10, sessions[0].StartSecond)
+	assert.Equal(t, 110, sessions[0].EndSecond)
+	assert.Equal(t, 100, sessions[0].WatchedSeconds)
+	assert.Equal(t, 112, sessions[0].TotalSeconds)
+	assert.True(t, sessions[0].Completed)
+
+	mf, err := db.GetMediaFile("/media/a.mkv")
+	require.NoError(t, err)
+	assert.True(t, mf.Watched)
+	assert.Equal(t, 1, mf.PlayCount)
+}
+
+func TestTrackerSkipsSeeksAndPauses(t *testing.T) {
+	tracker, db := newTestTracker(t)
+	start := time.Now()
+
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePlaying, 0, 1000, start)
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePlaying, 10, 1000, start.Add(10*time.Second))
+	// Seek forward by 500 seconds.
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePlaying, 511, 1000, start.Add(11*time.Second))
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePaused, 511, 1000, start.Add(20*time.Second))
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePaused, 511, 1000, start.Add(300*time.Second))
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePlaying, 515, 1000, start.Add(304*time.Second))
+	tracker.Close()
+
+	sessions := allSessions(t, db)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, 10, sessions[0].WatchedSeconds)
+	assert.Equal(t, 515, sessions[0].EndSecond)
+	assert.False(t, sessions[0].Completed)
+}
+
+func TestTrackerSplitsSessionsOnFileChange(t *testing.T) {
+	tracker, db := newTestTracker(t)
+	start := time.Now()
+
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePlaying, 0, 100, start)
+	tracker.Observe("/media/a.mkv", "a.mkv", models.StatePlaying, 5, 100, start.Add(5*time.Second))
+	tracker.Observe("/media/b.mkv", "b.mkv", models.StatePlaying, 0, 100, start.Add(6*time.Second))
+	tracker.Observe("/media/b.mkv", "b.mkv", models.StatePlaying, 7, 100, start.Add(13*time.Second))
+	// Opening a file without watching is ignored.
+	tracker.Observe("/media/c.mkv", "c.mkv", models.StatePlaying, 0, 100, start.Add(14*time.Second))
+	tracker.Close()
+
+	sessions := allSessions(t, db)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "b.mkv", sessions[0].Filename)
+	assert.Equal(t, 7, sessions[0].WatchedSeconds)
+	assert.Equal(t, "a.mkv", sessions[1].Filename)
+	assert.Equal(t, 5, sessions[1].WatchedSeconds)
+}
+
+func TestTrackerHandlesPlaybackEvents(t *testing.T) {
+	tracker, db := newTestTracker(t)
+	start := time.Now()
+
+	event := func(eventType, state string, position int, after time.Duration) models.PlaybackEvent {
+		e := models.NewPlaybackEvent(eventType, "/media/a.mkv", "a.mkv", position, 100, start.Add(after))
+		e.State = state
+		return e
+	}
+
+	tracker.Handle(event(models.EventStarted, models.StatePlaying, 0, 0))
+	for i := 1; i <= 91; i++ {
+		tracker.Handle(event(models.EventProgress, models.StatePlaying, i, time.Duration(i)*time.Second))
+	}
+	// The completed event carries the last position seen while playing, past the last progress event.
+	tracker.Handle(event(models.EventCompleted, models.StateStopped, 92, 92*time.Second))
+
+	sessions := allSessions(t, db)
+	require.Len(t, sessions, 1)
+	assert.True(t, sessions[0].Completed)
+	assert.Equal(t, 92, sessions[0].WatchedSeconds)
+	assert.Equal(t, 92, sessions[0].EndSecond)
+}
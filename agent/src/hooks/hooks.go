@@ -0,0 +1,226 @@
+// Package hooks runs the user's executables on playback events, for local automation
+// like dimming the lights when a file starts.
+//
+// A hook gets the event as VILLAIN_COUCH_* environment variables and as JSON on stdin.
+// Its output ends up in the log.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"villain-couch/agent/src/cli"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+)
+
+const (
+	// queueSize is how many events may wait for a free slot.
+	queueSize = 64
+	// maxOutput is how much of the output of a hook is logged.
+	maxOutput = 16 << 10
+	// killDelay is how long a hook that timed out gets to exit after the interrupt before it is killed.
+	killDelay = 2 * time.Second
+)
+
+// Runner runs the hooks in the background, at most MaxConcurrent of them at the same time.
+type Runner struct {
+	hooks   models.Hooks
+	timeout time.Duration
+	// closeTimeout is how long Close waits for the queued and running hooks before it kills them.
+	closeTimeout time.Duration
+
+	events chan models.PlaybackEvent
+	ctx    context.Context
+	cancel context.CancelFunc // kills the running hooks
+	wg     sync.WaitGroup
+}
+
+func New(hooks models.Hooks) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{
+		hooks:        hooks,
+		timeout:      hooks.GetTimeout(),
+		closeTimeout: hooks.GetTimeout(),
+		events:       make(chan models.PlaybackEvent, queueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Start starts the workers running the hooks.
+func (r *Runner) Start() {
+	for range r.hooks.GetMaxConcurrent() {
+		r.wg.Add(1)
+		go r.work()
+	}
+}
+
+// Handle is the subscriber of the event bus. It never blocks, if the queue is full the event is dropped.
+func (r *Runner) Handle(e models.PlaybackEvent) {
+	if r.hooks.Command(e.Type) == "" {
+		return
+	}
+	select {
+	case r.events <- e:
+	default:
+		logger.Log.Warn("hook queue is full, dropping event", "type", e.Type)
+	}
+}
+
+// Close waits for the queued and running hooks, e.g. on_exit, to finish.
+// Whatever still runs after closeTimeout is killed and the rest of the queue is dropped, so the agent exits in time.
+func (r *Runner) Close() {
+	close(r.events)
+	finished := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(finished)
+	}()
+
+	timer := time.NewTimer(r.closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-finished:
+	case <-timer.C:
+		logger.Log.Warn("hooks are taking too long, killing them", "timeout", r.closeTimeout)
+		r.cancel()
+		<-finished
+	}
+	r.cancel()
+}
+
+func (r *Runner) work() {
+	defer r.wg.Done()
+	for e := range r.events {
+		if r.ctx.Err() != nil {
+			logger.Log.Warn("agent is exiting, dropping hook", "type", e.Type)
+			continue
+		}
+		r.run(r.hooks.Command(e.Type), e)
+	}
+}
+
+// run executes a hook and logs how it went.
+func (r *Runner) run(path string, e models.PlaybackEvent) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		logger.Log.Error("could not encode event for hook", "hook", path, "type", e.Type, "error", err)
+		return
+	}
+
+	output := &limitedBuffer{limit: maxOutput}
+	runner := cli.NewCommandRunnerForHook(cli.PrepareHookRunnerArguments(path, Env(e), payload, output))
+	started := time.Now()
+	if err := runner.Start(); err != nil {
+		logger.Log.Error("could not start hook", "hook", path, "type", e.Type, "error", err)
+		return
+	}
+
+	err = r.wait(runner, path)
+	attrs := []any{"hook", path, "type", e.Type, "duration", time.Since(started).Round(time.Millisecond)}
+	if out := strings.TrimSpace(output.String()); out != "" {
+		attrs = append(attrs, "output", out)
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		logger.Log.Info("hook finished", attrs...)
+	case errors.As(err, &exitErr):
+		logger.Log.Warn("hook failed", append(attrs, "exit_code", exitErr.ExitCode())...)
+	default:
+		logger.Log.Warn("hook failed", append(attrs, "error", err)...)
+	}
+}
+
+// wait waits for a hook, one that runs longer than the timeout is interrupted and then killed.
+// A hook still running when Close gives up is killed right away.
+func (r *Runner) wait(runner *cli.CommandRunner, path string) error {
+	select {
+	case err := <-runner.Done():
+		return err
+	case <-time.After(r.timeout):
+		logger.Log.Warn("hook timed out, stopping it", "hook", path, "timeout", r.timeout)
+		if err := runner.Stop(); err != nil {
+			logger.Log.Error("could not stop hook", "hook", path, "error", err)
+		}
+		select {
+		case err := <-runner.Done():
+			return err
+		case <-time.After(killDelay):
+		case <-r.ctx.Done():
+		}
+	case <-r.ctx.Done():
+		logger.Log.Warn("agent is exiting, killing hook", "hook", path)
+	}
+
+	if err := runner.Kill(); err != nil {
+		logger.Log.Error("could not kill hook", "hook", path, "error", err)
+	}
+	return <-runner.Done()
+}
+
+// Env returns the event as environment variables, e.g. VILLAIN_COUCH_EVENT=completed.
+// Fields without a value are left out.
+func Env(e models.PlaybackEvent) []string {
+	env := []string{
+		"VILLAIN_COUCH_EVENT=" + e.Type,
+		"VILLAIN_COUCH_STATE=" + e.State,
+		"VILLAIN_COUCH_FILEPATH=" + e.Filepath,
+		"VILLAIN_COUCH_FILENAME=" + e.Filename,
+		"VILLAIN_COUCH_POSITION=" + strconv.Itoa(e.Position),
+		"VILLAIN_COUCH_LENGTH=" + strconv.Itoa(e.Length),
+		"VILLAIN_COUCH_PROGRESS=" + strconv.FormatFloat(e.Progress, 'f', 1, 64),
+		"VILLAIN_COUCH_AT=" + e.At.Format(time.RFC3339),
+	}
+	if e.Next != "" {
+		env = append(env, "VILLAIN_COUCH_NEXT="+e.Next)
+	}
+	if e.Show != "" {
+		env = append(env, "VILLAIN_COUCH_SHOW="+e.Show)
+	}
+	if e.Season > 0 {
+		env = append(env, "VILLAIN_COUCH_SEASON="+strconv.Itoa(e.Season))
+	}
+	if e.Episode > 0 {
+		env = append(env, "VILLAIN_COUCH_EPISODE="+strconv.Itoa(e.Episode))
+	}
+	return env
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest,
+// a chatty hook must not fill the memory.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		b.buf.Write(p[:max(room, 0)])
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.truncated {
+		return b.buf.String() + "\n[output truncated]"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,125 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScript writes an executable shell script to dir.
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts are shell scripts")
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755))
+	return path
+}
+
+func TestRunnerPassesEventToHook(t *testing.T) {
+	logger.Initialize(false)
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := writeScript(t, dir, "on_complete.sh", `env | grep '^VILLAIN_COUCH_' | sort > "`+out+`.env"
+cat > "`+out+`.json"
+echo done
+`)
+
+	r := New(models.Hooks{OnComplete: script})
+	r.Start()
+	e := models.NewPlaybackEvent(models.EventCompleted, "/media/Show.S01E02.mkv", "Show.S01E02.mkv", 99, 100, time.Now())
+	e.Show, e.Season, e.Episode = "Show", 1, 2
+	r.Handle(models.NewPlaybackEvent(models.EventStarted, "/media/a.mkv", "a.mkv", 0, 100, time.Now()))
+	r.Handle(e)
+	r.Close()
+
+	env, err := os.ReadFile(out + ".env")
+	require.NoError(t, err)
+	assert.Contains(t, string(env), "VILLAIN_COUCH_EVENT=completed\n")
+	assert.Contains(t, string(env), "VILLAIN_COUCH_FILEPATH=/media/Show.S01E02.mkv\n")
+	assert.Contains(t, string(env), "VILLAIN_COUCH_POSITION=99\n")
+	assert.Contains(t, string(env), "VILLAIN_COUCH_SEASON=1\n")
+	assert.NotContains(t, string(env), "VILLAIN_COUCH_NEXT=")
+
+	stdin, err := os.ReadFile(out + ".json")
+	require.NoError(t, err)
+	var got models.PlaybackEvent
+	require.NoError(t, json.Unmarshal(stdin, &got))
+	assert.Equal(t, models.EventCompleted, got.Type)
+	assert.Equal(t, "Show", got.Show)
+}
+
+func TestRunnerStopsHooksAfterTimeout(t *testing.T) {
+	logger.Initialize(false)
+	script := writeScript(t, t.TempDir(), "on_start.sh", "trap '' INT\nsleep 30\n")
+
+	r := New(models.Hooks{OnStart: script})
+	r.timeout = 50 * time.Millisecond
+	r.Start()
+	started := time.Now()
+	r.Handle(models.NewPlaybackEvent(models.EventStarted, "/media/a.mkv", "a.mkv", 0, 100, time.Now()))
+	r.Close()
+
+	// Interrupted, then killed after killDelay, instead of running for 30 seconds.
+	assert.Less(t, time.Since(started), 10*time.Second)
+}
+
+func TestRunnerCloseKillsSlowHooks(t *testing.T) {
+	logger.Initialize(false)
+	script := writeScript(t, t.TempDir(), "on_pause.sh", "trap '' INT\nsleep 30\n")
+
+	r := New(models.Hooks{OnPause: script, MaxConcurrent: 1})
+	r.closeTimeout = 200 * time.Millisecond
+	r.Start()
+	for range queueSize {
+		r.Handle(models.NewPlaybackEvent(models.EventPaused, "/media/a.mkv", "a.mkv", 10, 100, time.Now()))
+	}
+	started := time.Now()
+	r.Close()
+
+	// Without the deadline every queued hook would run into its 30 second timeout.
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
+
+func TestRunnerLimitsConcurrency(t *testing.T) {
+	logger.Initialize(false)
+	dir := t.TempDir()
+	// Every run appends its start and end, overlapping runs would interleave.
+	log := filepath.Join(dir, "log")
+	script := writeScript(t, dir, "on_pause.sh", `echo start >> "`+log+`"
+sleep 0.1
+echo end >> "`+log+`"
+`)
+
+	r := New(models.Hooks{OnPause: script, MaxConcurrent: 1})
+	r.Start()
+	for range 3 {
+		r.Handle(models.NewPlaybackEvent(models.EventPaused, "/media/a.mkv", "a.mkv", 10, 100, time.Now()))
+	}
+	r.Close()
+
+	content, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("start\nend\n", 3), string(content))
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{limit: 5}
+	n, err := b.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = b.Write([]byte("defgh"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n, "the hook must not see a short write")
+	assert.Equal(t, "abcde\n[output truncated]", b.String())
+}
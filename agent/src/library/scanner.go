@@ -0,0 +1,212 @@
+package library
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/ff"
+	"villain-couch/common/logger"
+)
+
+// ScanStats summarizes a scan.
+type ScanStats struct {
+	ScannedDirs int // directories read from disk
+	SkippedDirs int // directories with an unchanged mtime
+	Files       int // video files indexed in the scanned directories
+	RemovedDirs int // directories that disappeared since the last scan
+}
+
+// Scanner fills the library index with the video files of the workspaces.
+//
+// Scans are incremental: a directory whose mtime did not change since the last scan still has the
+// same entries, so it is not read again and its subdirectories are taken from the index.
+// Files that are replaced in place keep the directory mtime, use Rescan to pick those up.
+type Scanner struct {
+	db *storage.DB
+	// full disables the mtime check.
+	full bool
+}
+
+func NewScanner(db *storage.DB) *Scanner {
+	return &Scanner{db: db}
+}
+
+// Rescan returns a scanner that reads every directory, ignoring the recorded mtimes.
+func (s *Scanner) Rescan() *Scanner {
+	return &Scanner{db: s.db, full: true}
+}
+
+// ScanAll scans every workspace. A workspace that cannot be read (e.g. an unplugged drive)
+// is skipped and keeps its index, an error is returned after all workspaces were tried.
+func (s *Scanner) ScanAll() (ScanStats, error) {
+	workspaces, err := s.db.GetWorkspaces()
+	if err != nil {
+		return ScanStats{}, err
+	}
+
+	var total ScanStats
+	var errs []error
+	for _, ws := range workspaces {
+		stats, err := s.ScanWorkspace(ws)
+		total.add(stats)
+		if err != nil {
+			logger.Log.Warn("could not scan workspace", "workspace", ws.DirectoryPath, "error", err)
+			errs = append(errs, err)
+		}
+	}
+	return total, errors.Join(errs...)
+}
+
+// Lookup runs find against the index. The index may be behind the workspaces, so if find reports
+// that it found nothing, every workspace is scanned and find runs once more.
+// Unchanged directories are skipped, so this is cheap compared to a full walk.
+func (s *Scanner) Lookup(find func() (bool, error)) error {
+	if found, err := find(); err != nil || found {
+		return err
+	}
+	if _, err := s.ScanAll(); err != nil {
+		logger.Log.Warn("Could not scan every workspace", "error", err)
+	}
+	_, err := find()
+	return err
+}
+
+// ScanWorkspace scans a single workspace.
+func (s *Scanner) ScanWorkspace(ws models.Workspace) (ScanStats, error) {
+	var stats ScanStats
+	root := filepath.Clean(ws.DirectoryPath)
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+
+	err := s.scanDir(ws.ID, root, filepath.Dir(root), false, &stats)
+	logger.Log.Info("scanned workspace", "workspace", root, "scanned", stats.ScannedDirs, "skipped", stats.SkippedDirs, "files", stats.Files)
+	return stats, err
+}
+
+// ScanDirectory scans a directory that belongs to a workspace, e.g. after a change was noticed in it.
+// The directory itself is always read, its subdirectories are scanned incrementally.
+func (s *Scanner) ScanDirectory(workspaceID int, dir string) (ScanStats, error) {
+	var stats ScanStats
+	err := s.scanDir(workspaceID, dir, filepath.Dir(dir), true, &stats)
+	return stats, err
+}
+
+func (s *Scanner) scanDir(workspaceID int, dir, parent string, force bool, stats *ScanStats) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("could not stat directory '%s': %w", dir, err)
+	}
+
+	indexed, err := s.db.GetLibraryDirectory(dir)
+	if err != nil {
+		return err
+	}
+
+	if !s.full && !force && indexed != nil && indexed.WorkspaceID == workspaceID && indexed.ModifiedAt.Equal(info.ModTime()) {
+		stats.SkippedDirs++
+		subdirs, err := s.db.GetLibrarySubdirectories(dir)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subdirs {
+			if err := s.scanDir(workspaceID, sub, dir, false, stats); err != nil {
+				// The subdirectory is gone but the parent mtime did not change, e.g. a restored backup.
+				logger.Log.Warn("could not scan indexed directory", "directory", sub, "error", err)
+				if err := s.db.DeleteLibraryDirectory(sub); err != nil {
+					return err
+				}
+				stats.RemovedDirs++
+			}
+		}
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("could not read directory '%s': %w", dir, err)
+	}
+	stats.ScannedDirs++
+
+	var files []models.LibraryFile
+	var subdirs []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			subdirs = append(subdirs, path)
+			continue
+		}
+		if !ff.IsVideoFile(path) {
+			continue
+		}
+
+		fileInfo, err := entry.Info()
+		if err != nil {
+			logger.Log.Warn("could not stat file", "file", path, "error", err)
+			continue
+		}
+		files = append(files, NewLibraryFile(workspaceID, path, fileInfo))
+	}
+
+	// Forget subdirectories that were removed or renamed.
+	known, err := s.db.GetLibrarySubdirectories(dir)
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(subdirs))
+	for _, sub := range subdirs {
+		present[sub] = true
+	}
+	for _, sub := range known {
+		if !present[sub] {
+			if err := s.db.DeleteLibraryDirectory(sub); err != nil {
+				return err
+			}
+			stats.RemovedDirs++
+		}
+	}
+
+	d := models.LibraryDirectory{Directory: dir, Parent: parent, WorkspaceID: workspaceID, ModifiedAt: info.ModTime()}
+	if err := s.db.SetLibraryDirectory(d, files); err != nil {
+		return err
+	}
+	stats.Files += len(files)
+
+	for _, sub := range subdirs {
+		if err := s.scanDir(workspaceID, sub, dir, false, stats); err != nil {
+			logger.Log.Warn("could not scan directory", "directory", sub, "error", err)
+		}
+	}
+	return nil
+}
+
+// NewLibraryFile builds the index entry of a video file, parsing show, season and episode from its name.
+func NewLibraryFile(workspaceID int, path string, info os.FileInfo) models.LibraryFile {
+	f := models.LibraryFile{
+		Filepath:    path,
+		Directory:   filepath.Dir(path),
+		Filename:    filepath.Base(path),
+		WorkspaceID: workspaceID,
+		Size:        info.Size(),
+		ModifiedAt:  info.ModTime(),
+		Quality:     ff.VideoQuality(path),
+	}
+
+	if episode, err := ff.ParseEpisodeInfo(path); err == nil {
+		f.ShowName = episode.ShowName
+		f.ShowKey = ff.ShowKey(episode.ShowName)
+		f.Season = episode.Season
+		f.Episode = episode.Episode
+	}
+	return f
+}
+
+func (s *ScanStats) add(o ScanStats) {
+	s.ScannedDirs += o.ScannedDirs
+	s.SkippedDirs += o.SkippedDirs
+	s.Files += o.Files
+	s.RemovedDirs += o.RemovedDirs
+}
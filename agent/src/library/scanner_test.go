@@ -0,0 +1,183 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func addWorkspace(t *testing.T, db *storage.DB, dir string) models.Workspace {
+	now := time.Now()
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: dir, DirectoryName: filepath.Base(dir), CreatedAt: now, UpdatedAt: now}))
+	workspaces, err := db.GetWorkspaces()
+	require.NoError(t, err)
+	for _, ws := range workspaces {
+		if ws.DirectoryPath == dir {
+			return ws
+		}
+	}
+	t.Fatalf("workspace %s was not added", dir)
+	return models.Workspace{}
+}
+
+func touch(t *testing.T, path string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte("video"), 0o644))
+}
+
+func TestScanWorkspaceIsIncremental(t *testing.T) {
+	db := storagetest.NewDB(t)
+	root := t.TempDir()
+	touch(t, filepath.Join(root, "The Office", "Season 1", "The.Office.S01E01.720p.mkv"))
+	touch(t, filepath.Join(root, "The Office", "Season 1", "The.Office.S01E02.720p.mkv"))
+	touch(t, filepath.Join(root, "The Office", "Season 1", "notes.txt"))
+	touch(t, filepath.Join(root, "Dark", "Dark.S01E01.mkv"))
+	ws := addWorkspace(t, db, root)
+	scanner := NewScanner(db)
+
+	stats, err := scanner.ScanWorkspace(ws)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.ScannedDirs)
+	assert.Equal(t, 3, stats.Files)
+
+	next, err := db.FindNextLibraryEpisode("office", 1, 1)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, "The.Office.S01E02.720p.mkv", next.Filename)
+	assert.Equal(t, "The Office", next.ShowName)
+	assert.Equal(t, 720, next.Quality)
+	assert.Equal(t, ws.ID, next.WorkspaceID)
+
+	// Nothing changed, every directory is skipped.
+	stats, err = scanner.ScanWorkspace(ws)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.ScannedDirs)
+	assert.Equal(t, 4, stats.SkippedDirs)
+
+	// Removing a directory drops its files, a new episode is picked up from the changed directory only.
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "Dark")))
+	season1 := filepath.Join(root, "The Office", "Season 1")
+	touch(t, filepath.Join(season1, "The.Office.S01E03.1080p.mkv"))
+	// Some filesystems have a coarse mtime resolution, make sure the change is visible.
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(season1, later, later))
+	require.NoError(t, os.Chtimes(root, later, later))
+
+	stats, err = scanner.ScanWorkspace(ws)
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.ScannedDirs)
+	assert.Equal(t, 1, stats.SkippedDirs)
+	assert.Equal(t, 1, stats.RemovedDirs)
+
+	next, err = db.FindNextLibraryEpisode("dark", 1, 0)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+
+	next, err = db.FindNextLibraryEpisode("office", 1, 2)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, "The.Office.S01E03.1080p.mkv", next.Filename)
+}
+
+func TestFindNextLibraryEpisodePrefersQualityThenWorkspace(t *testing.T) {
+	db := storagetest.NewDB(t)
+	first, second := t.TempDir(), t.TempDir()
+	touch(t, filepath.Join(first, "Dark.S01E02.720p.mkv"))
+	touch(t, filepath.Join(second, "Dark.S01E02.1080p.mkv"))
+	touch(t, filepath.Join(first, "Dark.S01E03.mkv"))
+	touch(t, filepath.Join(second, "Dark.S01E03.mkv"))
+
+	_, err := NewScanner(db).ScanWorkspace(addWorkspace(t, db, first))
+	require.NoError(t, err)
+	_, err = NewScanner(db).ScanWorkspace(addWorkspace(t, db, second))
+	require.NoError(t, err)
+
+	next, err := db.FindNextLibraryEpisode("dark", 1, 1)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, filepath.Join(second, "Dark.S01E02.1080p.mkv"), next.Filepath)
+
+	next, err = db.FindNextLibraryEpisode("dark", 1, 2)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, filepath.Join(first, "Dark.S01E03.mkv"), next.Filepath)
+
+	// Episodes of a removed workspace are not offered.
+	require.NoError(t, db.RemoveWorkspace(1))
+	next, err = db.FindNextLibraryEpisode("dark", 1, 2)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, filepath.Join(second, "Dark.S01E03.mkv"), next.Filepath)
+}
+
+func TestScanWorkspaceKeepsIndexOfOfflineWorkspace(t *testing.T) {
+	db := storagetest.NewDB(t)
+	root := t.TempDir()
+	touch(t, filepath.Join(root, "Dark.S01E02.mkv"))
+	ws := addWorkspace(t, db, root)
+
+	_, err := NewScanner(db).ScanWorkspace(ws)
+	require.NoError(t, err)
+
+	ws.DirectoryPath = filepath.Join(root, "unplugged")
+	_, err = NewScanner(db).ScanWorkspace(ws)
+	require.Error(t, err)
+
+	next, err := db.FindNextLibraryEpisode("dark", 1, 1)
+	require.NoError(t, err)
+	assert.NotNil(t, next)
+}
+
+func TestFindPreviousLibraryEpisode(t *testing.T) {
+	db := storagetest.NewDB(t)
+	root := t.TempDir()
+	touch(t, filepath.Join(root, "Dark", "Season 1", "Dark.S01E09.mkv"))
+	touch(t, filepath.Join(root, "Dark", "Season 1", "Dark.S01E10.720p.mkv"))
+	touch(t, filepath.Join(root, "Dark", "Season 1", "Dark.S01E10.1080p.mkv"))
+	touch(t, filepath.Join(root, "Dark", "Season 2", "Dark.S02E01.mkv"))
+	_, err := NewScanner(db).ScanWorkspace(addWorkspace(t, db, root))
+	require.NoError(t, err)
+
+	prev, err := db.FindPreviousLibraryEpisode("dark", 2, 1)
+	require.NoError(t, err)
+	require.NotNil(t, prev)
+	assert.Equal(t, "Dark.S01E10.1080p.mkv", prev.Filename)
+
+	prev, err = db.FindPreviousLibraryEpisode("dark", 1, 9)
+	require.NoError(t, err)
+	assert.Nil(t, prev)
+}
+
+func TestLookupScansWhenTheIndexIsBehind(t *testing.T) {
+	db := storagetest.NewDB(t)
+	root := t.TempDir()
+	addWorkspace(t, db, root)
+	touch(t, filepath.Join(root, "Dark", "Dark.S01E01.mkv"))
+
+	calls := 0
+	var files []models.LibraryFile
+	err := NewScanner(db).Lookup(func() (bool, error) {
+		calls++
+		var err error
+		files, err = db.GetLibraryShowFiles("dark", -1)
+		return len(files) > 0, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Len(t, files, 1)
+
+	// Found in the index, no scan.
+	calls = 0
+	require.NoError(t, NewScanner(db).Lookup(func() (bool, error) {
+		calls++
+		return true, nil
+	}))
+	assert.Equal(t, 1, calls)
+}
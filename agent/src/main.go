@@ -1,7 +1,6 @@
 package main
 
 import (
-	"errors"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -12,10 +11,15 @@ import (
 	"villain-couch/agent/src/cli"
 	"villain-couch/agent/src/cli/operations"
 	"villain-couch/agent/src/config"
+	"villain-couch/agent/src/events"
+	"villain-couch/agent/src/history"
+	"villain-couch/agent/src/hooks"
 	mediaplayer "villain-couch/agent/src/media-player"
 	"villain-couch/agent/src/models"
 	"villain-couch/agent/src/options"
 	"villain-couch/agent/src/storage"
+	"villain-couch/agent/src/watcher"
+	"villain-couch/agent/src/webhook"
 	"villain-couch/common/logger"
 )
 
@@ -23,14 +27,89 @@ func main() {
 	bootstrap.Bootstrap()
 	flags, db, opts, conf := cli.GetFlags(), storage.GetDB(), options.GetOptions(), config.GetConfig()
 	operations.New().Build(flags, db, opts).Sort().Run().Finalize() // What in the Java...
-	vlc := mediaplayer.New(conf, opts)
-	run(&vlc, opts)
+	player := mediaplayer.New(conf, opts)
+	storage.GetFlusher().Start(conf.GetCheckpointInterval())
+	startWorkspaceWatcher(db, conf)
+	startAPI(db, conf)
+	startWebhooks(db, conf)
+	startHooks(conf)
+	setupEvents(player, opts, conf.GetCompletion())
+	run(player)
 }
 
-func run(vlc *mediaplayer.VLCMediaPlayer, opts *options.Options) {
+// workspaceWatcher keeps the library index up to date while playing, nil if it could not be started.
+var workspaceWatcher *watcher.Watcher
+
+func startWorkspaceWatcher(db *storage.DB, conf *config.Config) {
+	workspaces, err := db.GetWorkspaces()
+	if err != nil || len(workspaces) == 0 {
+		return
+	}
+
+	w, err := watcher.New(db, conf.GetWatchDebounce())
+	if err != nil {
+		logger.Log.Warn("could not start workspace watcher", "error", err)
+		return
+	}
+	w.Start(workspaces)
+	workspaceWatcher = w
+}
+
+var (
+	// detector turns the ticks into playback events.
+	detector *events.Detector
+	// bus hands the playback events to the subscribers.
+	bus *events.Bus
+)
+
+// setupEvents creates the event bus with its subscribers. They run in the order they subscribed:
+// progress is saved and the watch session recorded before the next episode is started.
+// The completion tells a file that played to the end apart from one the user stopped.
+func setupEvents(player mediaplayer.MediaPlayer, opts *options.Options, completion models.Completion) {
+	detector = events.NewDetector(completion)
+	bus = events.NewBus()
+	bus.Subscribe("persistence", events.NewPersistence(storage.GetCache(), storage.GetFlusher()).Handle)
+	bus.Subscribe("history", history.NewTracker(storage.GetDB(), completion).Handle)
+	bus.Subscribe("logging", events.Log)
+	bus.Subscribe("season", events.NewSeasonCompletion(storage.GetDB(), bus).Handle)
+	bus.Subscribe("auto-advance", events.NewAutoAdvance(player, opts, bus).Handle)
+	if apiServer != nil {
+		bus.Subscribe("api", apiServer.Publish)
+	}
+	if webhooks != nil {
+		bus.Subscribe("webhooks", webhooks.Handle)
+	}
+	if hookRunner != nil {
+		bus.Subscribe("hooks", hookRunner.Handle)
+	}
+}
+
+// webhooks delivers the playback events to the configured webhooks, nil if there are none.
+var webhooks *webhook.Dispatcher
+
+func startWebhooks(db *storage.DB, conf *config.Config) {
+	if len(conf.Webhooks) == 0 {
+		return
+	}
+	webhooks = webhook.New(db, conf.Webhooks)
+	webhooks.Start()
+}
+
+// hookRunner runs the configured hook scripts, nil if there are none.
+var hookRunner *hooks.Runner
+
+func startHooks(conf *config.Config) {
+	if conf.Hooks.IsEmpty() {
+		return
+	}
+	hookRunner = hooks.New(conf.Hooks)
+	hookRunner.Start()
+}
+
+func run(player mediaplayer.MediaPlayer) {
 	logger.Log.Info("------ Starting VilLain Couch [VLC Tracker] ------")
 
-	if err := vlc.CommandRunner.Start(); err != nil {
+	if err := player.Runner().Start(); err != nil {
 		logger.Log.Error("Failed to start command", "error", err)
 		os.Exit(1)
 	}
@@ -41,7 +120,7 @@ func run(vlc *mediaplayer.VLCMediaPlayer, opts *options.Options) {
 
 	for {
 		select {
-		case err := <-vlc.CommandRunner.Done():
+		case err := <-player.Runner().Done():
 			// The Done channel is closed, and the final error state is received.
 			if err != nil {
 				// The error "signal: interrupt" is expected here because we stopped it.
@@ -49,7 +128,21 @@ func run(vlc *mediaplayer.VLCMediaPlayer, opts *options.Options) {
 			}
 
 			// TODO Post Close handle here
-			saveMediaStates()
+			// The subscribers save the progress and end the watch session.
+			bus.Publish(detector.Exit(time.Now()))
+			if apiServer != nil {
+				apiServer.Close()
+			}
+			if workspaceWatcher != nil {
+				workspaceWatcher.Close()
+			}
+			if webhooks != nil {
+				webhooks.Close()
+			}
+			if hookRunner != nil {
+				// Waits for on_exit.
+				hookRunner.Close()
+			}
 			bootstrap.Teardown()
 			os.Exit(0)
 			// Exit the for loop somehow
@@ -58,88 +151,45 @@ func run(vlc *mediaplayer.VLCMediaPlayer, opts *options.Options) {
 			logger.Log.Info("Received signal, initiating graceful shutdown.", "signal", sig.String())
 
 			// Stop the background process.
-			if err := vlc.CommandRunner.Stop(); err != nil {
+			if err := player.Runner().Stop(); err != nil {
 				logger.Log.Error("Failed to send stop signal to command", "error", err)
 			}
 
 			// Wait for the command to fully terminate.
-			<-vlc.CommandRunner.Done()
+			<-player.Runner().Done()
 			logger.Log.Info("Background command stopped successfully.")
 
+		case cmd := <-apiCommands():
+			cmd.Done <- executeCommand(player, cmd)
+
 		case <-time.After(500 * time.Millisecond):
 			// This case executes if the Done channel is not ready yet.
-			handleTick(vlc, opts)
+			handleTick(player)
 		}
 	}
 }
 
-func handleTick(vlc *mediaplayer.VLCMediaPlayer, opts *options.Options) {
-	status, err := vlc.Status()
+func handleTick(player mediaplayer.MediaPlayer) {
+	status, err := player.Status()
 	if err != nil {
-		logger.Log.Error("VLC GetStatus Error", "error", err)
+		logger.Log.Error("Media Player GetStatus Error", "error", err)
 		// ignore error
 	}
 
-	playlist, err := vlc.Playlist()
+	playlist, err := player.Playlist()
 	if err != nil {
-		logger.Log.Error("VLC GetPlaylist Error", "error", err)
+		logger.Log.Error("Media Player GetPlaylist Error", "error", err)
 		// ignore error
 	}
 
 	currentFilepath, err := playlist.GetCurrent()
 	if err != nil {
-		logger.Log.Error("VLC GetCurrent Error", "error", err)
+		logger.Log.Error("Media Player GetCurrent Error", "error", err)
 		// ignore error
 	}
 
-	vlc.LogStatus(status)
-
-	// If file is completed then stop VLC.
-	// You don't need to update cache since it will be empty string.
-	if status.GetState() == models.StateStopped {
-		saveMediaStates()
-		storage.GetCache().Delete(currentFilepath)
-		if err := vlc.TryNext(currentFilepath); err != nil {
-			if errors.Is(err, mediaplayer.ErrorMediaFileNotFound) {
-				if opts.FuzzyFoundNextEpisode != "" {
-					err := vlc.PlayFile(opts.FuzzyFoundNextEpisode)
-					if err != nil {
-						logger.Log.Error("VLC PlayFile Error on Fuzzy Found Next Episode", "error", err, "path", opts.FuzzyFoundNextEpisode)
-						_ = vlc.CommandRunner.Stop()
-					}
-				} else {
-					// in macOS we need to handle this as well
-					logger.Log.Warn("cannot play next file", "error", err)
-					_ = vlc.CommandRunner.Stop()
-				}
-			} else {
-				logger.Log.Warn("cannot play next file", "error", err)
-				_ = vlc.CommandRunner.Stop()
-			}
-
-		}
-	} else {
-		mf := models.NewMediaFileFromStatus(status, currentFilepath)
-		storage.GetCache().Set(mf.Filepath, mf)
-	}
-}
-
-func saveMediaStates() {
-	logger.Log.Info("Saving media states...")
-	db := storage.GetDB()
-	cache := storage.GetCache()
-	for _, key := range cache.Keys() {
-		// If empty filepath then do not add it to db
-		if key == "" {
-			continue
-		}
-		val, _ := cache.Get(key)
-		err := db.SetMediaFile(val)
-		if err != nil {
-			logger.Log.Error("could not save state to database", "error", err.Error())
-			return
-		}
-	}
+	setNowPlaying(status, currentFilepath)
+	bus.Publish(detector.Observe(status, currentFilepath, time.Now())...)
 }
 
 // clearConsole clears the terminal screen.
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"villain-couch/agent/src/api"
+	"villain-couch/agent/src/library"
+	mediaplayer "villain-couch/agent/src/media-player"
+	"villain-couch/agent/src/media-player/fakevlc"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/options"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupAgent(t *testing.T) (*fakevlc.Server, mediaplayer.MediaPlayer, *options.Options) {
+	logger.Initialize(false)
+	require.NoError(t, storage.Initialize(filepath.Join(t.TempDir(), "storage.sqlite")))
+	t.Cleanup(storage.Shutdown)
+
+	fake := fakevlc.New("secret")
+	t.Cleanup(fake.Close)
+
+	opts := &options.Options{}
+	player := mediaplayer.NewVLC(fake.Config(), opts)
+	setupEvents(player, opts, models.DefaultCompletion)
+	nowPlaying = api.NowPlaying{State: models.StateStopped}
+	return fake, player, opts
+}
+
+func createFiles(t *testing.T, dir string, names ...string) []string {
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestHandleTickAdvancesToNextEpisode(t *testing.T) {
+	fake, player, _ := setupAgent(t)
+	episodes := createFiles(t, t.TempDir(), "Show.S01E01.mkv", "Show.S01E02.mkv")
+
+	fake.Load(episodes[0], 100)
+	fake.Advance(40)
+	handleTick(player)
+
+	cached, found := storage.GetCache().Get(episodes[0])
+	require.True(t, found)
+	assert.Equal(t, 40, cached.CurrentSecond)
+	assert.Equal(t, 100, cached.TotalSeconds)
+
+	fake.Advance(55)
+	handleTick(player)
+	fake.Finish()
+	handleTick(player)
+
+	saved, err := storage.GetDB().GetMediaFile(episodes[0])
+	require.NoError(t, err)
+	assert.Equal(t, 95, saved.CurrentSecond)
+
+	_, found = storage.GetCache().Get(episodes[0])
+	assert.False(t, found)
+	assert.Equal(t, episodes[1], fake.Current())
+
+	fake.AdvancePerStatus(10)
+	handleTick(player)
+
+	cached, found = storage.GetCache().Get(episodes[1])
+	require.True(t, found)
+	assert.Equal(t, fake.Time(), cached.CurrentSecond)
+}
+
+func TestHandleTickPlaysFuzzyFoundNextEpisode(t *testing.T) {
+	fake, player, opts := setupAgent(t)
+	current := createFiles(t, t.TempDir(), "Show.S01E09.mkv")[0]
+	next := createFiles(t, t.TempDir(), "Show.S01E10.mkv")[0]
+	opts.FuzzyFoundNextEpisode = next
+
+	fake.Load(current, 100)
+	fake.Advance(99)
+	handleTick(player)
+	fake.Finish()
+	handleTick(player)
+
+	assert.Equal(t, next, fake.Current())
+}
+
+func TestHandleTickManualStopKeepsPosition(t *testing.T) {
+	fake, player, _ := setupAgent(t)
+	episodes := createFiles(t, t.TempDir(), "Show.S01E01.mkv", "Show.S01E02.mkv")
+
+	fake.Load(episodes[0], 100)
+	fake.Advance(50)
+	handleTick(player)
+	// The user presses stop halfway through.
+	fake.SetState(models.StateStopped)
+	handleTick(player)
+	handleTick(player)
+
+	assert.Equal(t, episodes[0], fake.Current())
+	saved, err := storage.GetDB().GetMediaFile(episodes[0])
+	require.NoError(t, err)
+	assert.Equal(t, 50, saved.CurrentSecond)
+	cached, found := storage.GetCache().Get(episodes[0])
+	require.True(t, found)
+	assert.Equal(t, 50, cached.CurrentSecond)
+}
+
+func TestHandleTickUsesCompletionThreshold(t *testing.T) {
+	fake, player, opts := setupAgent(t)
+	episodes := createFiles(t, t.TempDir(), "Show.S01E01.mkv", "Show.S01E02.mkv")
+	setupEvents(player, opts, models.Completion{RemainingSeconds: 30})
+
+	// Stopped in the credits.
+	fake.Load(episodes[0], 100)
+	fake.Advance(75)
+	handleTick(player)
+	fake.SetState(models.StateStopped)
+	handleTick(player)
+
+	assert.Equal(t, episodes[1], fake.Current())
+}
+
+func TestHandleTickPausedKeepsProgress(t *testing.T) {
+	fake, player, _ := setupAgent(t)
+	episode := createFiles(t, t.TempDir(), "Show.S01E01.mkv")[0]
+
+	fake.Load(episode, 100)
+	fake.Advance(25)
+	fake.SetState(models.StatePaused)
+	handleTick(player)
+	require.NoError(t, storage.GetFlusher().Flush())
+
+	saved, err := storage.GetDB().GetMediaFile(episode)
+	require.NoError(t, err)
+	assert.Equal(t, 25, saved.CurrentSecond)
+	assert.Equal(t, episode, fake.Current())
+}
+
+func TestExecuteCommandPlaysNeighbouringEpisodes(t *testing.T) {
+	fake, player, _ := setupAgent(t)
+	root := t.TempDir()
+	season1 := filepath.Join(root, "Season 1")
+	season2 := filepath.Join(root, "Season 2")
+	require.NoError(t, os.MkdirAll(season1, 0755))
+	require.NoError(t, os.MkdirAll(season2, 0755))
+	first := createFiles(t, season1, "Show.S01E01.mkv", "Show.S01E02.mkv")
+	second := createFiles(t, season2, "Show.S02E01.mkv")
+
+	// Nothing is playing yet.
+	err := executeCommand(player, api.Command{Action: api.ActionNext})
+	assert.ErrorIs(t, err, api.ErrNotFound)
+
+	fake.Load(first[1], 100)
+	handleTick(player)
+	assert.Equal(t, first[1], getNowPlaying().Filepath)
+
+	require.NoError(t, executeCommand(player, api.Command{Action: api.ActionNext}))
+	assert.Equal(t, second[0], fake.Current())
+
+	handleTick(player)
+	require.NoError(t, executeCommand(player, api.Command{Action: api.ActionPrevious}))
+	assert.Equal(t, first[1], fake.Current())
+
+	handleTick(player)
+	require.NoError(t, executeCommand(player, api.Command{Action: api.ActionPrevious}))
+	assert.Equal(t, first[0], fake.Current())
+
+	handleTick(player)
+	err = executeCommand(player, api.Command{Action: api.ActionPrevious})
+	assert.ErrorIs(t, err, api.ErrNotFound)
+
+	require.NoError(t, executeCommand(player, api.Command{Action: api.ActionPause}))
+	assert.Equal(t, models.StatePaused, fake.State())
+	require.NoError(t, executeCommand(player, api.Command{Action: api.ActionResume}))
+	assert.Equal(t, models.StatePlaying, fake.State())
+}
+
+func TestExecuteCommandPrefersPreviousEpisodeOfSameSeasonInLibrary(t *testing.T) {
+	fake, player, _ := setupAgent(t)
+	root := t.TempDir()
+	season1 := filepath.Join(root, "Season 1")
+	season2 := filepath.Join(root, "Season 2")
+	elsewhere := filepath.Join(t.TempDir(), "Show")
+	for _, dir := range []string{season1, season2, elsewhere} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	last := createFiles(t, season1, "Show.S01E10.mkv")
+	current := createFiles(t, season2, "Show.S02E05.mkv")
+	missing := createFiles(t, elsewhere, "Show.S02E04.mkv")
+
+	db := storage.GetDB()
+	now := time.Now()
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: elsewhere, DirectoryName: "Show", CreatedAt: now, UpdatedAt: now}))
+	_, err := library.NewScanner(db).ScanAll()
+	require.NoError(t, err)
+
+	// E04 is not next to E05, but the library has it.
+	fake.Load(current[0], 100)
+	handleTick(player)
+	require.NoError(t, executeCommand(player, api.Command{Action: api.ActionPrevious}))
+	assert.Equal(t, missing[0], fake.Current())
+
+	// Without it, the previous season next to the current one is played.
+	require.NoError(t, os.Remove(missing[0]))
+	fake.Load(current[0], 100)
+	handleTick(player)
+	require.NoError(t, executeCommand(player, api.Command{Action: api.ActionPrevious}))
+	assert.Equal(t, last[0], fake.Current())
+}
@@ -0,0 +1,337 @@
+// Package fakevlc is a scriptable, in-process fake of VLC's HTTP interface.
+//
+// It serves requests/status.json and requests/playlist.json, checks the basic auth
+// password, understands the playback commands the agent sends, and simulates playback
+// moving forward. Tests drive it either directly (Load, Advance, Finish) or through the
+// agent's media player, then inspect what happened with Current, Time and Commands.
+//
+//	vlc := fakevlc.New("secret")
+//	defer vlc.Close()
+//	vlc.Load("/media/Show.S01E01.mkv", 1200)
+//	vlc.Advance(30)
+package fakevlc
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"villain-couch/agent/src/config"
+	"villain-couch/agent/src/models"
+)
+
+const (
+	StatusEndpoint   = "requests/status.json"
+	PlaylistEndpoint = "requests/playlist.json"
+
+	// DefaultLength is the length in seconds of files loaded with in_play that have no length set.
+	DefaultLength = 1200
+)
+
+// Command is a single command received on the status endpoint, e.g. in_play or seek.
+type Command struct {
+	Name  string
+	Input string
+	Val   string
+}
+
+// Server is the fake VLC. All methods are safe for concurrent use.
+type Server struct {
+	server   *httptest.Server
+	password string
+
+	mu       sync.Mutex
+	state    string
+	time     int
+	length   int
+	current  string
+	playlist []string
+	lengths  map[string]int
+	commands []Command
+
+	// advancePerStatus is how many seconds playback moves forward on every status request.
+	advancePerStatus int
+}
+
+// New starts a fake VLC listening on a random localhost port.
+func New(password string) *Server {
+	s := &Server{
+		password: password,
+		state:    models.StateStopped,
+		lengths:  make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+StatusEndpoint, s.handleStatus)
+	mux.HandleFunc("/"+PlaylistEndpoint, s.handlePlaylist)
+	s.server = httptest.NewServer(s.requireAuth(mux))
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL returns the base URL of the server, e.g. http://127.0.0.1:53211.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Config returns an agent config pointing at this server.
+func (s *Server) Config() *config.Config {
+	host, port, _ := net.SplitHostPort(s.server.Listener.Addr().String())
+	return &config.Config{
+		WebUrl:           "http://" + host,
+		StatusEndpoint:   StatusEndpoint,
+		PlaylistEndpoint: PlaylistEndpoint,
+		HttpPort:         port,
+		ExtraIntf:        "http",
+		HttpPassword:     s.password,
+		MediaPlayer:      config.MediaPlayerVLC,
+	}
+}
+
+// SetLength sets the length in seconds used when path is played with in_play.
+func (s *Server) SetLength(path string, length int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lengths[path] = length
+}
+
+// AdvancePerStatus makes playback move forward by seconds on every status request,
+// which simulates time passing while the agent polls.
+func (s *Server) AdvancePerStatus(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advancePerStatus = seconds
+}
+
+// Load starts playing path from the beginning, as if VLC was launched with it.
+func (s *Server) Load(path string, length int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lengths[path] = length
+	s.play(path)
+}
+
+// Advance moves playback forward. Reaching the end stops playback like VLC does.
+func (s *Server) Advance(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(seconds)
+}
+
+// Finish jumps to the end of the current file.
+func (s *Server) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(s.length - s.time)
+}
+
+// SetState overrides the playback state.
+func (s *Server) SetState(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+}
+
+// State returns the playback state.
+func (s *Server) State() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Time returns the playback position in seconds.
+func (s *Server) Time() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.time
+}
+
+// Current returns the path of the current playlist item.
+func (s *Server) Current() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// Commands returns every command received so far, oldest first.
+func (s *Server) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+}
+
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// VLC ignores the user name and only checks the password.
+		_, password, ok := r.BasicAuth()
+		if !ok || password != s.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="VLC stream"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	if name := query.Get("command"); name != "" {
+		cmd := Command{Name: name, Input: query.Get("input"), Val: query.Get("val")}
+		s.commands = append(s.commands, cmd)
+		if !s.apply(cmd) {
+			http.Error(w, "bad command", http.StatusBadRequest)
+			return
+		}
+	} else if s.state == models.StatePlaying {
+		s.advance(s.advancePerStatus)
+	}
+
+	var status models.VLCStatus
+	status.State = s.state
+	status.Time = s.time
+	status.Length = s.length
+	if s.current != "" {
+		status.Information.Category.Meta.Filename = filepath.Base(s.current)
+	}
+	writeJSON(w, status)
+}
+
+func (s *Server) handlePlaylist(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	playlist := models.VLCPlaylistNode{ID: "1", Name: "Playlist", Type: "node"}
+	for i, path := range s.playlist {
+		leaf := models.VLCPlaylistNode{
+			ID:   strconv.Itoa(i + 3),
+			Name: filepath.Base(path),
+			Type: "leaf",
+			URI:  fileURI(path),
+		}
+		if path == s.current {
+			leaf.Current = "current"
+		}
+		playlist.Children = append(playlist.Children, leaf)
+	}
+
+	root := models.VLCPlaylistNode{ID: "0", Type: "node", Children: []models.VLCPlaylistNode{playlist}}
+	writeJSON(w, root)
+}
+
+// apply changes the playback state for a command. It returns false for unusable commands.
+func (s *Server) apply(cmd Command) bool {
+	switch cmd.Name {
+	case "in_play":
+		// Parse the URI the same way the agent parses playlist items.
+		leaf := models.VLCPlaylistNode{Type: "leaf", Current: "current", URI: cmd.Input}
+		path, err := leaf.GetCurrent()
+		if err != nil || path == "" {
+			return false
+		}
+		s.play(path)
+	case "seek":
+		second, err := strconv.Atoi(cmd.Val)
+		if err != nil {
+			return false
+		}
+		s.time = min(max(second, 0), s.length)
+	case "pl_pause":
+		switch s.state {
+		case models.StatePlaying:
+			s.state = models.StatePaused
+		case models.StatePaused:
+			s.state = models.StatePlaying
+		}
+	case "pl_forcepause":
+		if s.state == models.StatePlaying {
+			s.state = models.StatePaused
+		}
+	case "pl_play", "pl_forceresume":
+		if s.current != "" {
+			s.state = models.StatePlaying
+		}
+	case "pl_stop":
+		s.stop()
+	case "pl_next", "pl_previous":
+		step := 1
+		if cmd.Name == "pl_previous" {
+			step = -1
+		}
+		for i, path := range s.playlist {
+			if path == s.current && i+step >= 0 && i+step < len(s.playlist) {
+				s.play(s.playlist[i+step])
+				break
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *Server) play(path string) {
+	found := false
+	for _, p := range s.playlist {
+		if p == path {
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.playlist = append(s.playlist, path)
+	}
+
+	length, ok := s.lengths[path]
+	if !ok {
+		length = DefaultLength
+	}
+
+	s.current = path
+	s.state = models.StatePlaying
+	s.time = 0
+	s.length = length
+}
+
+func (s *Server) advance(seconds int) {
+	if s.current == "" {
+		return
+	}
+	s.time += seconds
+	if s.time >= s.length {
+		s.stop()
+	}
+}
+
+// stop mirrors VLC: the current item stays in the playlist, but time and length are reset.
+func (s *Server) stop() {
+	s.state = models.StateStopped
+	s.time = 0
+	s.length = 0
+}
+
+func fileURI(path string) string {
+	path = filepath.ToSlash(path)
+	// Windows paths like "C:/..." need a leading slash, see encoding.FormatFileURI.
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u := url.URL{Scheme: "file", Path: path}
+	return u.String()
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,59 @@
+package media_player
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"villain-couch/agent/src/cli"
+	"villain-couch/agent/src/config"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/options"
+	"villain-couch/common/logger"
+	re "villain-couch/common/regex"
+)
+
+type MediaPlayer interface {
+	Build(*config.Config, *options.Options)
+	Runner() *cli.CommandRunner
+	Status() (models.StatusMessage, error)
+	Playlist() (models.PlaylistMessage, error)
+	PlayFile(filepath string) error
+	SeekSecond(second string) error
+	Pause(paused bool) error
+	TryNext(currentFilepath string) error
+}
+
+var (
+	ErrorMediaFileNotFound = errors.New("media file not found")
+)
+
+// New creates the media player backend selected by the `media_player` config key.
+func New(conf *config.Config, opts *options.Options) MediaPlayer {
+	switch conf.GetMediaPlayer() {
+	case config.MediaPlayerMPV:
+		return NewMPV(conf, opts)
+	default:
+		return NewVLC(conf, opts)
+	}
+}
+
+// tryNext plays the next episode of currentFilepath on the given player.
+// It can return following errors
+// 1. Next episode name is in wrong format
+// 2. Next episode media file not found
+// 3. Player API Error (PlayFile)
+func tryNext(player MediaPlayer, currentFilepath string) error {
+	nextEpisodeName, ok := re.GetNextEpisodeFilename(currentFilepath)
+	if !ok {
+		logger.Log.Error("could not find next episode filename", "current", currentFilepath)
+		return fmt.Errorf("could not find next episode filename")
+	}
+
+	// Check if the media file exists before trying to play it.
+	if _, err := os.Stat(nextEpisodeName); os.IsNotExist(err) {
+		logger.Log.Warn("Media file not found", "Media File", nextEpisodeName)
+		return ErrorMediaFileNotFound
+	}
+
+	return player.PlayFile(nextEpisodeName)
+}
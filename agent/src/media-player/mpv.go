@@ -0,0 +1,307 @@
+package media_player
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+	"villain-couch/agent/src/cli"
+	"villain-couch/agent/src/config"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/options"
+	"villain-couch/common/logger"
+)
+
+// errPropertyUnavailable is returned by mpv for properties that have no value yet,
+// e.g. time-pos while no file is loaded.
+var errPropertyUnavailable = errors.New("property unavailable")
+
+// MPVMediaPlayer drives mpv through its JSON IPC socket (--input-ipc-server).
+// The socket is a unix domain socket, so this backend is meant for Linux and macOS.
+type MPVMediaPlayer struct {
+	Args          cli.MPVRunnerArguments
+	CommandRunner *cli.CommandRunner
+
+	// lastPath is the last file mpv reported. mpv forgets the path once it goes idle,
+	// but the agent still needs it to find the next episode.
+	lastPath  string
+	requestID int
+}
+
+func NewMPV(conf *config.Config, opts *options.Options) *MPVMediaPlayer {
+	mpv := &MPVMediaPlayer{}
+	mpv.Build(conf, opts)
+	return mpv
+}
+
+func (mpv *MPVMediaPlayer) Build(conf *config.Config, opts *options.Options) {
+	mpv.Args = cli.PrepareMPVRunnerArguments(opts.MPVPath, opts.MediaFilePath, opts.MediaFileStartTime, conf.GetMPVIPCSocket())
+	mpv.CommandRunner = cli.NewCommandRunnerForMPV(mpv.Args)
+}
+
+func (mpv *MPVMediaPlayer) Runner() *cli.CommandRunner {
+	return mpv.CommandRunner
+}
+
+func (mpv *MPVMediaPlayer) Status() (models.StatusMessage, error) {
+	var status models.MPVStatus
+
+	conn, err := mpv.dial()
+	if err != nil {
+		logger.Log.Error("could not connect to mpv's IPC socket", "error", err.Error())
+		return status, err
+	}
+	defer conn.Close()
+
+	idle, err := conn.getBool("idle-active")
+	if err != nil {
+		return status, err
+	}
+	eof, err := conn.getBool("eof-reached")
+	if err != nil {
+		return status, err
+	}
+	paused, err := conn.getBool("pause")
+	if err != nil {
+		return status, err
+	}
+	position, err := conn.getFloat("time-pos")
+	if err != nil {
+		return status, err
+	}
+	duration, err := conn.getFloat("duration")
+	if err != nil {
+		return status, err
+	}
+	path, err := conn.getString("path")
+	if err != nil {
+		return status, err
+	}
+	filename, err := conn.getString("filename")
+	if err != nil {
+		return status, err
+	}
+	title, err := conn.getString("media-title")
+	if err != nil {
+		return status, err
+	}
+
+	if path != "" {
+		mpv.lastPath = path
+	}
+
+	// With --keep-open mpv stays paused on the last frame, eof-reached tells us the file is done.
+	switch {
+	case idle || eof:
+		status.State = models.StateStopped
+	case paused:
+		status.State = models.StatePaused
+	default:
+		status.State = models.StatePlaying
+	}
+	status.Time = int(position)
+	status.Length = int(duration)
+	status.Filename = filename
+	status.Title = title
+
+	return status, nil
+}
+
+func (mpv *MPVMediaPlayer) Playlist() (models.PlaylistMessage, error) {
+	playlist := models.MPVPlaylist{Path: mpv.lastPath}
+
+	conn, err := mpv.dial()
+	if err != nil {
+		logger.Log.Error("could not connect to mpv's IPC socket", "error", err.Error())
+		return playlist, err
+	}
+	defer conn.Close()
+
+	path, err := conn.getString("path")
+	if err != nil {
+		return playlist, err
+	}
+
+	if path != "" {
+		mpv.lastPath = path
+		playlist.Path = path
+	}
+
+	return playlist, nil
+}
+
+func (mpv *MPVMediaPlayer) PlayFile(filepath string) error {
+	conn, err := mpv.dial()
+	if err != nil {
+		logger.Log.Error("could not connect to mpv's IPC socket", "error", err.Error())
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.call("loadfile", filepath, "replace"); err != nil {
+		return fmt.Errorf("mpv could not load file: %w", err)
+	}
+
+	// mpv pauses on the last frame with --keep-open, so resume playback explicitly.
+	if _, err := conn.call("set_property", "pause", false); err != nil {
+		return fmt.Errorf("mpv could not resume playback: %w", err)
+	}
+
+	mpv.lastPath = filepath
+	logger.Log.Info("played file", "file", filepath)
+	return nil
+}
+
+func (mpv *MPVMediaPlayer) SeekSecond(second string) error {
+	seconds, err := strconv.Atoi(second)
+	if err != nil {
+		return fmt.Errorf("invalid second '%s': %w", second, err)
+	}
+
+	conn, err := mpv.dial()
+	if err != nil {
+		logger.Log.Error("could not connect to mpv's IPC socket", "error", err.Error())
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.call("seek", seconds, "absolute"); err != nil {
+		return fmt.Errorf("mpv could not seek: %w", err)
+	}
+
+	logger.Log.Info("seeked", "second", second)
+	return nil
+}
+
+// Pause pauses or resumes playback.
+func (mpv *MPVMediaPlayer) Pause(paused bool) error {
+	conn, err := mpv.dial()
+	if err != nil {
+		logger.Log.Error("could not connect to mpv's IPC socket", "error", err.Error())
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.call("set_property", "pause", paused); err != nil {
+		return fmt.Errorf("mpv could not pause: %w", err)
+	}
+
+	logger.Log.Info("paused", "paused", paused)
+	return nil
+}
+
+// TryNext plays the next episode of currentFilepath, see tryNext for possible errors.
+func (mpv *MPVMediaPlayer) TryNext(currentFilepath string) error {
+	return tryNext(mpv, currentFilepath)
+}
+
+func (mpv *MPVMediaPlayer) dial() (*mpvConn, error) {
+	conn, err := net.DialTimeout("unix", mpv.Args.IPCSocket, 3*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+	return &mpvConn{conn: conn, reader: bufio.NewReader(conn), requestID: &mpv.requestID}, nil
+}
+
+// mpvConn is a single connection to mpv's IPC socket.
+type mpvConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	requestID *int
+}
+
+type mpvRequest struct {
+	Command   []any `json:"command"`
+	RequestID int   `json:"request_id"`
+}
+
+type mpvResponse struct {
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error"`
+	RequestID int             `json:"request_id"`
+	Event     string          `json:"event"`
+}
+
+func (c *mpvConn) Close() error {
+	return c.conn.Close()
+}
+
+// call sends a command and waits for its reply.
+// mpv writes events to the same socket, those are skipped.
+func (c *mpvConn) call(command ...any) (json.RawMessage, error) {
+	*c.requestID++
+	req := mpvRequest{Command: command, RequestID: *c.requestID}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mpv command: %w", err)
+	}
+
+	if _, err := c.conn.Write(append(payload, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write to mpv: %w", err)
+	}
+
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from mpv: %w", err)
+		}
+
+		var res mpvResponse
+		if err := json.Unmarshal(line, &res); err != nil {
+			logger.Log.Warn("could not decode mpv's JSON response", "error", err.Error())
+			continue
+		}
+
+		if res.Event != "" || res.RequestID != req.RequestID {
+			continue
+		}
+
+		switch res.Error {
+		case "success":
+			return res.Data, nil
+		case errPropertyUnavailable.Error():
+			return nil, errPropertyUnavailable
+		default:
+			return nil, fmt.Errorf("mpv returned an error: %s", res.Error)
+		}
+	}
+}
+
+// getProperty reads a property into v. Unavailable properties leave v untouched.
+func (c *mpvConn) getProperty(name string, v any) error {
+	data, err := c.call("get_property", name)
+	if errors.Is(err, errPropertyUnavailable) {
+		return nil
+	}
+	if err != nil {
+		logger.Log.Error("could not get mpv property", "property", name, "error", err.Error())
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("could not decode mpv property '%s': %w", name, err)
+	}
+	return nil
+}
+
+func (c *mpvConn) getBool(name string) (bool, error) {
+	var v bool
+	err := c.getProperty(name, &v)
+	return v, err
+}
+
+func (c *mpvConn) getFloat(name string) (float64, error) {
+	var v float64
+	err := c.getProperty(name, &v)
+	return v, err
+}
+
+func (c *mpvConn) getString(name string) (string, error) {
+	var v string
+	err := c.getProperty(name, &v)
+	return v, err
+}
@@ -0,0 +1,134 @@
+package media_player
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"villain-couch/agent/src/cli"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMPV answers get_property requests from a property map and records every other command.
+type fakeMPV struct {
+	mu         sync.Mutex
+	properties map[string]any
+	commands   [][]any
+}
+
+func (f *fakeMPV) serve(t *testing.T, socket string) {
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+}
+
+func (f *fakeMPV) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req mpvRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return
+		}
+
+		// Interleave an event to make sure the client skips it.
+		_, _ = conn.Write([]byte(`{"event":"property-change"}` + "\n"))
+
+		f.mu.Lock()
+		res := map[string]any{"request_id": req.RequestID, "error": "success"}
+		if req.Command[0] == "get_property" {
+			value, ok := f.properties[req.Command[1].(string)]
+			if ok {
+				res["data"] = value
+			} else {
+				res["error"] = "property unavailable"
+			}
+		} else {
+			f.commands = append(f.commands, req.Command)
+		}
+		f.mu.Unlock()
+
+		payload, _ := json.Marshal(res)
+		_, _ = conn.Write(append(payload, '\n'))
+	}
+}
+
+func newTestMPV(t *testing.T, properties map[string]any) (*MPVMediaPlayer, *fakeMPV) {
+	logger.Initialize(false)
+	socket := filepath.Join(t.TempDir(), "mpv.sock")
+	fake := &fakeMPV{properties: properties}
+	fake.serve(t, socket)
+	return &MPVMediaPlayer{Args: cli.MPVRunnerArguments{IPCSocket: socket}}, fake
+}
+
+func TestMPVStatus(t *testing.T) {
+	mpv, _ := newTestMPV(t, map[string]any{
+		"idle-active": false,
+		"eof-reached": false,
+		"pause":       true,
+		"time-pos":    65.4,
+		"duration":    1320.9,
+		"path":        "/media/Show.S01E02.mkv",
+		"filename":    "Show.S01E02.mkv",
+		"media-title": "Show",
+	})
+
+	status, err := mpv.Status()
+	require.NoError(t, err)
+	assert.Equal(t, models.StatePaused, status.GetState())
+	assert.Equal(t, 65, status.GetTime())
+	assert.Equal(t, 1320, status.GetLength())
+	assert.Equal(t, "Show.S01E02.mkv", status.GetFilename())
+
+	playlist, err := mpv.Playlist()
+	require.NoError(t, err)
+	current, _ := playlist.GetCurrent()
+	assert.Equal(t, "/media/Show.S01E02.mkv", current)
+}
+
+func TestMPVStatusIdleKeepsLastPath(t *testing.T) {
+	mpv, _ := newTestMPV(t, map[string]any{"idle-active": true})
+	mpv.lastPath = "/media/Show.S01E02.mkv"
+
+	status, err := mpv.Status()
+	require.NoError(t, err)
+	assert.Equal(t, models.StateStopped, status.GetState())
+
+	playlist, err := mpv.Playlist()
+	require.NoError(t, err)
+	current, _ := playlist.GetCurrent()
+	assert.Equal(t, "/media/Show.S01E02.mkv", current)
+}
+
+func TestMPVPlayFileAndSeek(t *testing.T) {
+	mpv, fake := newTestMPV(t, map[string]any{})
+
+	require.NoError(t, mpv.PlayFile("/media/Show.S01E03.mkv"))
+	require.NoError(t, mpv.SeekSecond("42"))
+	require.NoError(t, mpv.Pause(true))
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	assert.Equal(t, [][]any{
+		{"loadfile", "/media/Show.S01E03.mkv", "replace"},
+		{"set_property", "pause", false},
+		{"seek", float64(42), "absolute"},
+		{"set_property", "pause", true},
+	}, fake.commands)
+}
@@ -2,11 +2,9 @@ package media_player
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
 	"time"
 	"villain-couch/agent/src/cli"
 	"villain-couch/agent/src/config"
@@ -14,20 +12,6 @@ import (
 	"villain-couch/agent/src/options"
 	"villain-couch/common/encoding"
 	"villain-couch/common/logger"
-	re "villain-couch/common/regex"
-)
-
-type MediaPlayer interface {
-	Build(*config.Config, *options.Options)
-	Status() (models.StatusMessage, error)
-	Playlist() (models.PlaylistMessage, error)
-	PlayFile(filepath string) error
-	TryNext() error
-	LogStatus(s models.StatusMessage)
-}
-
-var (
-	ErrorMediaFileNotFound = errors.New("media file not found")
 )
 
 type VLCMediaPlayer struct {
@@ -37,19 +21,23 @@ type VLCMediaPlayer struct {
 	PlaylistEndpoint string
 }
 
-func New(conf *config.Config, opts *options.Options) VLCMediaPlayer {
-	vlc := VLCMediaPlayer{}
+func NewVLC(conf *config.Config, opts *options.Options) *VLCMediaPlayer {
+	vlc := &VLCMediaPlayer{}
 	vlc.Build(conf, opts)
 	return vlc
 }
 
 func (vlc *VLCMediaPlayer) Build(conf *config.Config, opts *options.Options) {
-	vlc.Args = cli.PrepareRunnerArguments(opts.VLCPath, opts.MediaFilePath, opts.MediaFileStartTime, conf.ExtraIntf, conf.HttpPort, conf.HttpPassword)
+	vlc.Args = cli.PrepareRunnerArguments(opts.VLCPath, opts.VLCArgs, opts.MediaFilePath, opts.MediaFileStartTime, conf.ExtraIntf, conf.HttpPort, conf.HttpPassword)
 	vlc.CommandRunner = cli.NewCommandRunnerForVLC(vlc.Args)
 	vlc.StatusEndpoint = fmt.Sprintf("%s:%s/%s", conf.WebUrl, conf.HttpPort, conf.StatusEndpoint)
 	vlc.PlaylistEndpoint = fmt.Sprintf("%s:%s/%s", conf.WebUrl, conf.HttpPort, conf.PlaylistEndpoint)
 }
 
+func (vlc *VLCMediaPlayer) Runner() *cli.CommandRunner {
+	return vlc.CommandRunner
+}
+
 func (vlc *VLCMediaPlayer) Status() (models.StatusMessage, error) {
 	var status models.VLCStatus
 	const user = ""
@@ -72,12 +60,12 @@ func (vlc *VLCMediaPlayer) Status() (models.StatusMessage, error) {
 
 	if res.StatusCode != http.StatusOK {
 		logger.Log.Error("vlc returned a non-200 status code", "status_code", res.StatusCode)
-		return status, err
+		return status, fmt.Errorf("VLC returned non-200 status: %s", res.Status)
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(&status); err != nil {
 		logger.Log.Error("could not decode VLC's JSON response", "error", err.Error())
-		return nil, err
+		return status, err
 	}
 
 	return &status, nil
@@ -105,12 +93,12 @@ func (vlc *VLCMediaPlayer) Playlist() (models.PlaylistMessage, error) {
 
 	if res.StatusCode != http.StatusOK {
 		logger.Log.Error("vlc returned a non-200 status code", "status_code", res.StatusCode)
-		return playlist, err
+		return playlist, fmt.Errorf("VLC returned non-200 status: %s", res.Status)
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(&playlist); err != nil {
 		logger.Log.Error("could not decode VLC's JSON response", "error", err.Error())
-		return nil, err
+		return playlist, err
 	}
 
 	return &playlist, nil
@@ -187,28 +175,37 @@ func (vlc *VLCMediaPlayer) SeekSecond(second string) error {
 	return nil
 }
 
-// TryNext can return following errors
-// 1. Next episode name is in wrong format
-// 2. Next episode media file not found
-// 3. VLC API Error (PlayFile)
-func (vlc *VLCMediaPlayer) TryNext(currentFilepath string) error {
-	nextEpisodeName, ok := re.GetNextEpisodeFilename(currentFilepath)
-	if !ok {
-		logger.Log.Error("could not find next episode filename", "current", currentFilepath)
-		return fmt.Errorf("could not find next episode filename")
+// Pause pauses or resumes playback. Unlike VLC's pl_pause it does not toggle.
+func (vlc *VLCMediaPlayer) Pause(paused bool) error {
+	command := "pl_forceresume"
+	if paused {
+		command = "pl_forcepause"
 	}
 
-	// Check if the media file exists before trying to launch VLC.
-	if _, err := os.Stat(nextEpisodeName); os.IsNotExist(err) {
-		logger.Log.Warn("Media file not found", "Media File", nextEpisodeName)
-		return ErrorMediaFileNotFound
+	client := &http.Client{Timeout: 3 * time.Second}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?command=%s", vlc.StatusEndpoint, command), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	return vlc.PlayFile(nextEpisodeName)
+	req.SetBasicAuth("", vlc.Args.HttpPassword)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.Log.Error("could not connect to VLC's web interface", "error", err.Error())
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("VLC returned non-200 status: %s", resp.Status)
+	}
+
+	logger.Log.Info("paused", "paused", paused)
+	return nil
 }
 
-func (vlc *VLCMediaPlayer) LogStatus(s models.StatusMessage) {
-	currentTime := fmt.Sprintf("%02d:%02d:%02d", s.GetTime()/3600, (s.GetTime()%3600)/60, s.GetTime()%60)
-	totalTime := fmt.Sprintf("%02d:%02d:%02d", s.GetLength()/3600, (s.GetLength()%3600)/60, s.GetLength()%60)
-	logger.Log.Info("Pinged", "Filename", s.GetFilename(), "State", s.GetState(), "Time", currentTime, "Total Time", totalTime)
+// TryNext plays the next episode of currentFilepath, see tryNext for possible errors.
+func (vlc *VLCMediaPlayer) TryNext(currentFilepath string) error {
+	return tryNext(vlc, currentFilepath)
 }
@@ -0,0 +1,92 @@
+package media_player
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"villain-couch/agent/src/media-player/fakevlc"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/options"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVLC(t *testing.T) (*VLCMediaPlayer, *fakevlc.Server) {
+	logger.Initialize(false)
+	fake := fakevlc.New("secret")
+	t.Cleanup(fake.Close)
+	return NewVLC(fake.Config(), &options.Options{}), fake
+}
+
+func TestVLCStatus(t *testing.T) {
+	vlc, fake := newTestVLC(t)
+	fake.Load("/media/Show.S01E02.mkv", 1320)
+	fake.Advance(65)
+
+	status, err := vlc.Status()
+	require.NoError(t, err)
+	assert.Equal(t, models.StatePlaying, status.GetState())
+	assert.Equal(t, 65, status.GetTime())
+	assert.Equal(t, 1320, status.GetLength())
+	assert.Equal(t, "Show.S01E02.mkv", status.GetFilename())
+
+	playlist, err := vlc.Playlist()
+	require.NoError(t, err)
+	current, err := playlist.GetCurrent()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("/media/Show.S01E02.mkv"), current)
+}
+
+func TestVLCStatusWrongPassword(t *testing.T) {
+	vlc, _ := newTestVLC(t)
+	vlc.Args.HttpPassword = "wrong"
+
+	_, err := vlc.Status()
+	assert.Error(t, err)
+
+	_, err = vlc.Playlist()
+	assert.Error(t, err)
+}
+
+func TestVLCPlayFile(t *testing.T) {
+	vlc, fake := newTestVLC(t)
+	path := filepath.FromSlash("/media/My Show/Show.S01E03.mkv")
+
+	require.NoError(t, vlc.PlayFile(path))
+
+	assert.Equal(t, path, fake.Current())
+	assert.Equal(t, 1, fake.Time())
+	commands := fake.Commands()
+	require.Len(t, commands, 2)
+	assert.Equal(t, "in_play", commands[0].Name)
+	assert.Equal(t, fakevlc.Command{Name: "seek", Val: "1"}, commands[1])
+}
+
+func TestVLCTryNext(t *testing.T) {
+	vlc, fake := newTestVLC(t)
+	dir := t.TempDir()
+	current := filepath.Join(dir, "Show.S01E01.mkv")
+	next := filepath.Join(dir, "Show.S01E02.mkv")
+
+	err := vlc.TryNext(current)
+	assert.ErrorIs(t, err, ErrorMediaFileNotFound)
+
+	require.NoError(t, os.WriteFile(next, nil, 0644))
+	require.NoError(t, vlc.TryNext(current))
+	assert.Equal(t, next, fake.Current())
+}
+
+func TestVLCPause(t *testing.T) {
+	vlc, fake := newTestVLC(t)
+	fake.Load("/media/Show.S01E02.mkv", 1320)
+
+	require.NoError(t, vlc.Pause(true))
+	assert.Equal(t, models.StatePaused, fake.State())
+	// Pausing twice does not toggle.
+	require.NoError(t, vlc.Pause(true))
+	assert.Equal(t, models.StatePaused, fake.State())
+	require.NoError(t, vlc.Pause(false))
+	assert.Equal(t, models.StatePlaying, fake.State())
+}
@@ -0,0 +1,24 @@
+package models
+
+// DefaultCompletion is used when the completion threshold is not configured.
+var DefaultCompletion = Completion{Percent: 92}
+
+// Completion decides whether playback that stopped reached the end of a file,
+// or was stopped by the user before that.
+// A file is finished once Percent of it is reached, or when at most RemainingSeconds are left.
+// Zero values disable the corresponding check.
+type Completion struct {
+	Percent          float64
+	RemainingSeconds int
+}
+
+// IsFinished reports whether position in a file of length seconds counts as watched to the end.
+func (c Completion) IsFinished(position, length int) bool {
+	if length <= 0 {
+		return false
+	}
+	if c.Percent > 0 && float64(position) >= float64(length)*c.Percent/100 {
+		return true
+	}
+	return c.RemainingSeconds > 0 && length-position <= c.RemainingSeconds
+}
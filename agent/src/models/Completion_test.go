@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletionIsFinished(t *testing.T) {
+	tests := []struct {
+		name       string
+		completion Completion
+		position   int
+		length     int
+		expected   bool
+	}{
+		{"stopped halfway", DefaultCompletion, 600, 1200, false},
+		{"reached the percent", DefaultCompletion, 1104, 1200, true},
+		{"just before the percent", DefaultCompletion, 1103, 1200, false},
+		{"unknown length", DefaultCompletion, 600, 0, false},
+		{"in the credits", Completion{RemainingSeconds: 90}, 2610, 2700, true},
+		{"before the credits", Completion{RemainingSeconds: 90}, 2600, 2700, false},
+		{"either check", Completion{Percent: 99, RemainingSeconds: 90}, 2650, 2700, true},
+		{"both disabled", Completion{}, 1200, 1200, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.completion.IsFinished(tt.position, tt.length))
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// Hooks are executables run on playback events, configured in config.json.
+type Hooks struct {
+	OnStart          string `json:"on_start"`
+	OnPause          string `json:"on_pause"`
+	OnResume         string `json:"on_resume"`
+	OnStop           string `json:"on_stop"`
+	OnComplete       string `json:"on_complete"`
+	OnAdvance        string `json:"on_advance"`
+	OnSeasonComplete string `json:"on_season_complete"`
+	OnExit           string `json:"on_exit"`
+	// How long a hook may run before it is stopped. Defaults to 30 seconds.
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// How many hooks may run at the same time. Defaults to 2.
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+// hook is a configured executable with its config key.
+type hook struct {
+	key     string
+	command string
+}
+
+func (h Hooks) commands() []hook {
+	return []hook{
+		{"on_start", h.OnStart},
+		{"on_pause", h.OnPause},
+		{"on_resume", h.OnResume},
+		{"on_stop", h.OnStop},
+		{"on_complete", h.OnComplete},
+		{"on_advance", h.OnAdvance},
+		{"on_season_complete", h.OnSeasonComplete},
+		{"on_exit", h.OnExit},
+	}
+}
+
+// Command returns the executable run on an event type, empty if there is none.
+func (h Hooks) Command(eventType string) string {
+	switch eventType {
+	case EventStarted:
+		return h.OnStart
+	case EventPaused:
+		return h.OnPause
+	case EventResumed:
+		return h.OnResume
+	case EventStopped:
+		return h.OnStop
+	case EventCompleted:
+		return h.OnComplete
+	case EventAdvanced:
+		return h.OnAdvance
+	case EventSeasonCompleted:
+		return h.OnSeasonComplete
+	case EventExited:
+		return h.OnExit
+	}
+	return ""
+}
+
+// IsEmpty reports whether no hook is configured.
+func (h Hooks) IsEmpty() bool {
+	for _, hook := range h.commands() {
+		if hook.command != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTimeout returns how long a hook may run.
+func (h Hooks) GetTimeout() time.Duration {
+	if h.TimeoutSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(h.TimeoutSeconds) * time.Second
+}
+
+// GetMaxConcurrent returns how many hooks may run at the same time.
+func (h Hooks) GetMaxConcurrent() int {
+	if h.MaxConcurrent <= 0 {
+		return 2
+	}
+	return h.MaxConcurrent
+}
+
+// Validate checks that every configured hook is an executable.
+func (h Hooks) Validate() error {
+	if h.TimeoutSeconds < 0 {
+		return fmt.Errorf("invalid timeout_seconds %d, expected a positive number of seconds", h.TimeoutSeconds)
+	}
+	if h.MaxConcurrent < 0 {
+		return fmt.Errorf("invalid max_concurrent %d, expected a positive number", h.MaxConcurrent)
+	}
+	for _, hook := range h.commands() {
+		if hook.command == "" {
+			continue
+		}
+		if _, err := exec.LookPath(hook.command); err != nil {
+			return fmt.Errorf("%s: %w", hook.key, err)
+		}
+	}
+	return nil
+}
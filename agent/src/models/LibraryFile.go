@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// LibraryFile represents a row in the library_files table, a video file found in a workspace.
+type LibraryFile struct {
+	Filepath    string
+	Directory   string
+	Filename    string
+	WorkspaceID int
+	Size        int64
+	ModifiedAt  time.Time
+	ShowName    string
+	ShowKey     string // normalized show name, empty if the filename could not be parsed
+	Season      int
+	Episode     int
+	Quality     int
+}
+
+// LibraryDirectory represents a row in the library_directories table.
+// ModifiedAt is the directory's mtime at the last scan, an unchanged mtime means unchanged entries.
+type LibraryDirectory struct {
+	Directory   string
+	Parent      string
+	WorkspaceID int
+	ModifiedAt  time.Time
+}
+
+// LibraryShow is a show with indexed files, under the key and name of its canonical show.
+type LibraryShow struct {
+	ShowKey  string
+	ShowName string
+	Files    int
+}
@@ -0,0 +1,11 @@
+package models
+
+// MPVPlaylist holds the path of the file mpv is playing.
+// mpv reports a plain filesystem path, so no URI parsing is needed.
+type MPVPlaylist struct {
+	Path string
+}
+
+func (p MPVPlaylist) GetCurrent() (string, error) {
+	return p.Path, nil
+}
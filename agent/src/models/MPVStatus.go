@@ -0,0 +1,45 @@
+package models
+
+// MPVStatus holds the playback properties read from mpv's JSON IPC socket.
+// State uses the same values as VLC (playing, paused, stopped).
+type MPVStatus struct {
+	State    string
+	Time     int
+	Length   int
+	Filename string
+	Title    string
+}
+
+func (m MPVStatus) GetState() string {
+	return m.State
+}
+
+func (m MPVStatus) GetTime() int {
+	return m.Time
+}
+
+func (m MPVStatus) GetLength() int {
+	return m.Length
+}
+
+func (m MPVStatus) GetFilename() string {
+	return m.Filename
+}
+
+// mpv does not expose show metadata in a consistent way, so these are left empty.
+
+func (m MPVStatus) GetShowName() string {
+	return ""
+}
+
+func (m MPVStatus) GetTitle() string {
+	return m.Title
+}
+
+func (m MPVStatus) GetEpisodeNumber() string {
+	return ""
+}
+
+func (m MPVStatus) GetSeasonNumber() string {
+	return ""
+}
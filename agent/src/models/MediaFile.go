@@ -10,6 +10,10 @@ type MediaFile struct {
 	CurrentSecond int
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
+	// Watched is set once playback passes the completion threshold, or by -mark-watched.
+	Watched   bool
+	WatchedAt time.Time // zero when not watched
+	PlayCount int
 }
 
 func NewMediaFileFromStatus(v StatusMessage, s string) MediaFile {
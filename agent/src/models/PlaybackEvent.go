@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Types of playback events, derived from the state the agent sees on every tick.
+const (
+	EventStarted         = "started"          // a file started playing
+	EventPaused          = "paused"           // playback was paused
+	EventResumed         = "resumed"          // playback continued after a pause
+	EventSeeked          = "seeked"           // the position jumped, From holds the position before
+	EventProgress        = "progress"         // sent every 5 seconds while playing
+	EventStopped         = "stopped"          // playback stopped before the end, the position is kept
+	EventCompleted       = "completed"        // playback stopped at the end of the file
+	EventAdvanced        = "advanced"         // the next episode was started, Next holds its path
+	EventExited          = "exited"           // the media player was closed
+	EventSeasonCompleted = "season_completed" // the last available episode of a season was completed
+)
+
+// EventTick is sent on every tick with a file playing or paused, for the subscribers inside the agent
+// that keep track of the position. It is not one of EventTypes and never leaves the agent.
+const EventTick = "tick"
+
+// EventTypes lists every event type clients can receive.
+var EventTypes = []string{
+	EventStarted, EventPaused, EventResumed, EventSeeked, EventProgress,
+	EventStopped, EventCompleted, EventAdvanced, EventExited, EventSeasonCompleted,
+}
+
+// PlaybackEvent is a change of the playback state.
+type PlaybackEvent struct {
+	Type     string    `json:"type"`
+	State    string    `json:"state"` // the player state after the event
+	Filepath string    `json:"filepath"`
+	Filename string    `json:"filename"`
+	Position int       `json:"position"`
+	Length   int       `json:"length"`
+	Progress float64   `json:"progress"` // percent of the file played
+	From     int       `json:"from,omitempty"`
+	Next     string    `json:"next,omitempty"`
+	Show     string    `json:"show,omitempty"`
+	Season   int       `json:"season,omitempty"`
+	Episode  int       `json:"episode,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// IsInternal reports whether the event is only for the subscribers inside the agent.
+func (e PlaybackEvent) IsInternal() bool {
+	return e.Type == EventTick
+}
+
+// NewPlaybackEvent creates an event of the given type at position of a file of length seconds.
+func NewPlaybackEvent(eventType, filepath, filename string, position, length int, at time.Time) PlaybackEvent {
+	e := PlaybackEvent{Type: eventType, Filepath: filepath, Filename: filename, Position: position, Length: length, At: at}
+	if length > 0 {
+		e.Progress = float64(position) * 100 / float64(length)
+	}
+	return e
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ShowAlias maps another name of a show to its canonical name.
+type ShowAlias struct {
+	AliasKey     string
+	Alias        string
+	CanonicalKey string
+	Canonical    string
+	CreatedAt    time.Time
+}
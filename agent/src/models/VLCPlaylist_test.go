@@ -0,0 +1,40 @@
+package models
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVLCPlaylistNodeGetCurrent(t *testing.T) {
+	uri := "file:///media/My%20Show/Show.S01E02.mkv"
+	expected := "/media/My Show/Show.S01E02.mkv"
+	if runtime.GOOS == "windows" {
+		uri = "file:///D:/My%20Show/Show.S01E02.mkv"
+		expected = `D:\My Show\Show.S01E02.mkv`
+	}
+
+	root := VLCPlaylistNode{ID: "0", Type: "node", Children: []VLCPlaylistNode{
+		{ID: "1", Name: "Playlist", Type: "node", Children: []VLCPlaylistNode{
+			{ID: "3", Type: "leaf", URI: "file:///media/Show.S01E01.mkv"},
+			{ID: "4", Type: "leaf", URI: uri, Current: "current"},
+		}},
+		{ID: "2", Name: "Media Library", Type: "node"},
+	}}
+
+	got, err := root.GetCurrent()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash(expected), got)
+}
+
+func TestVLCPlaylistNodeGetCurrentNothingPlaying(t *testing.T) {
+	root := VLCPlaylistNode{ID: "0", Type: "node", Children: []VLCPlaylistNode{
+		{ID: "1", Name: "Playlist", Type: "node"},
+	}}
+
+	got, err := root.GetCurrent()
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
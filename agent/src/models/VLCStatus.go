@@ -2,6 +2,8 @@ package models
 
 const (
 	StateStopped = "stopped"
+	StatePlaying = "playing"
+	StatePaused  = "paused"
 )
 
 type StatusMessage interface {
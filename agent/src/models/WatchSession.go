@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// WatchSession represents a row in the watch_sessions table.
+// A session is a continuous viewing of a single file, from the moment it starts playing
+// until the file changes, playback stops or the agent exits.
+type WatchSession struct {
+	Filepath       string
+	Filename       string
+	StartedAt      time.Time
+	EndedAt        time.Time
+	StartSecond    int
+	EndSecond      int
+	TotalSeconds   int
+	WatchedSeconds int
+	Completed      bool
+}
+
+// WatchSessionFilter narrows down the sessions returned by the history query.
+// Zero values disable the corresponding filter.
+type WatchSessionFilter struct {
+	Show  string
+	Since time.Time
+	Until time.Time
+	Limit int
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"text/template"
+	"time"
+	"villain-couch/common/str"
+)
+
+// Webhook is an HTTP endpoint notified about playback events, configured in config.json.
+type Webhook struct {
+	URL string `json:"url"`
+	// Events are the event types sent to the webhook, e.g. ["started", "season_completed"].
+	Events []string `json:"events"`
+	// Secret signs the payload with HMAC-SHA256, no signature is sent without it.
+	Secret string `json:"secret"`
+	// Template renders the JSON payload from the event, e.g. {"text": {{json .Filename}}}.
+	// Without it the event itself is sent.
+	Template string `json:"template"`
+}
+
+// webhookFuncs are the functions available in payload templates.
+var webhookFuncs = template.FuncMap{
+	// json quotes a value, so file names with quotes keep the payload valid.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"clock": str.Clock,
+}
+
+// Validate checks the URL, the event types and the template.
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url '%s', expected an http or https url", w.URL)
+	}
+	if len(w.Events) == 0 {
+		return errors.New("no events given")
+	}
+	for _, e := range w.Events {
+		if !slices.Contains(EventTypes, e) {
+			return fmt.Errorf("unknown event '%s'", e)
+		}
+	}
+	if _, err := w.NewPayload(); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	return nil
+}
+
+// Wants reports whether the webhook is sent for an event type.
+func (w Webhook) Wants(eventType string) bool {
+	return slices.Contains(w.Events, eventType)
+}
+
+// WebhookPayload renders the body sent to a webhook, its template is parsed once.
+type WebhookPayload struct {
+	tmpl *template.Template // nil without a template
+}
+
+// NewPayload parses the template of the webhook.
+func (w Webhook) NewPayload() (*WebhookPayload, error) {
+	if w.Template == "" {
+		return &WebhookPayload{}, nil
+	}
+	tmpl, err := template.New("payload").Funcs(webhookFuncs).Option("missingkey=error").Parse(w.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &WebhookPayload{tmpl: tmpl}, nil
+}
+
+// Render renders the body sent for an event.
+func (p *WebhookPayload) Render(e PlaybackEvent) ([]byte, error) {
+	if p.tmpl == nil {
+		return json.Marshal(e)
+	}
+
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, e); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook template did not render valid JSON: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+// WebhookDelivery represents a row in the webhook_outbox table, a payload waiting to be delivered.
+type WebhookDelivery struct {
+	ID            int
+	URL           string
+	EventType     string
+	Payload       []byte
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
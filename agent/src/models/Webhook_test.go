@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook Webhook
+		wantErr bool
+	}{
+		{"valid", Webhook{URL: "https://example.com/hook", Events: []string{EventStarted}}, false},
+		{"valid template", Webhook{URL: "http://localhost:8080", Events: []string{EventCompleted}, Template: `{"text": {{json .Filename}}}`}, false},
+		{"no scheme", Webhook{URL: "example.com/hook", Events: []string{EventStarted}}, true},
+		{"wrong scheme", Webhook{URL: "ftp://example.com", Events: []string{EventStarted}}, true},
+		{"no events", Webhook{URL: "https://example.com"}, true},
+		{"unknown event", Webhook{URL: "https://example.com", Events: []string{"finished"}}, true},
+		{"broken template", Webhook{URL: "https://example.com", Events: []string{EventStarted}, Template: `{{.Filename`}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.webhook.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func render(t *testing.T, w Webhook, e PlaybackEvent) ([]byte, error) {
+	t.Helper()
+	payload, err := w.NewPayload()
+	require.NoError(t, err)
+	return payload.Render(e)
+}
+
+func TestWebhookPayload(t *testing.T) {
+	e := NewPlaybackEvent(EventCompleted, `/media/The "Show".S01E02.mkv`, `The "Show".S01E02.mkv`, 3725, 3800, time.Now())
+
+	// Without a template the event is sent as is.
+	payload, err := render(t, Webhook{}, e)
+	require.NoError(t, err)
+	var decoded PlaybackEvent
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, e.Filepath, decoded.Filepath)
+	assert.Equal(t, EventCompleted, decoded.Type)
+
+	payload, err = render(t, Webhook{Template: `{"text": {{json .Filename}}, "at": "{{clock .Position}}"}`}, e)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "The \"Show\".S01E02.mkv", "at": "01:02:05"}`, string(payload))
+
+	_, err = render(t, Webhook{Template: `{"text": {{.Filename}}}`}, e)
+	assert.Error(t, err, "unquoted strings do not render valid JSON")
+
+	_, err = render(t, Webhook{Template: `{"text": {{json .Missing}}}`}, e)
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package models
 import "time"
 
 type Workspace struct {
+	ID            int
 	DirectoryPath string
 	DirectoryName string
 	CreatedAt     time.Time
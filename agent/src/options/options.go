@@ -2,13 +2,18 @@ package options
 
 import (
 	"errors"
+	"fmt"
+	iofs "io/fs"
 	"os"
 	"path/filepath"
 	"strconv"
 	"villain-couch/agent/src/cli"
 	"villain-couch/agent/src/config"
+	"villain-couch/agent/src/models"
 	"villain-couch/agent/src/resolver"
 	"villain-couch/agent/src/storage"
+	"villain-couch/common/ff"
+	"villain-couch/common/fs"
 	"villain-couch/common/globals"
 	"villain-couch/common/logger"
 	"villain-couch/common/optional"
@@ -18,10 +23,16 @@ import (
 type Options struct {
 	// Generated Ones
 	VLCPath               string
+	VLCArgs               []string // launcher arguments placed before VLC's own, e.g. `run org.videolan.VLC` for flatpak
+	MPVPath               string
 	DatabaseFilePath      string
 	MediaFilePath         string
 	MediaFileStartTime    string
 	FuzzyFoundNextEpisode string
+	// MediaDirectoryFiles are the video files of a directory given with -file, in episode order.
+	MediaDirectoryFiles []string
+	// PickMediaFile is set when an operation picks the file to play, e.g. -continue or -show.
+	PickMediaFile bool
 }
 
 var opts *Options
@@ -32,10 +43,15 @@ func GetOptions() *Options {
 
 // Sets additional options after initalization
 func SetOptions(db *storage.DB) error {
-	if opts.MediaFilePath == "" {
-		file, err := db.GetLatestUpdatedMediaFile()
+	if len(opts.MediaDirectoryFiles) > 0 {
+		return putDirectoryEpisode(db)
+	}
+
+	if opts.MediaFilePath == "" && !opts.PickMediaFile {
+		// Files watched to the end are skipped, resume the latest one that is not finished yet.
+		file, err := db.GetLatestUnwatchedMediaFile()
 		if err != nil {
-			logger.Log.Error(err.Error(), "msg", "Error getting latest updated media file.")
+			logger.Log.Error(err.Error(), "msg", "Error getting latest unwatched media file.")
 			return err
 		}
 
@@ -46,9 +62,8 @@ func SetOptions(db *storage.DB) error {
 
 		opts.MediaFilePath = file.Filepath
 		seconds := "1"
-		if file.CurrentSecond >= file.TotalSeconds {
-			seconds = strconv.Itoa(file.TotalSeconds - 10)
-		} else {
+		// Marking a file unwatched resets its position, so it starts over.
+		if file.CurrentSecond > 0 && file.CurrentSecond < file.TotalSeconds {
 			seconds = strconv.Itoa(file.CurrentSecond)
 		}
 		opts.MediaFileStartTime = seconds
@@ -58,6 +73,10 @@ func SetOptions(db *storage.DB) error {
 }
 
 func ValidateOptions() {
+	// The operation picking the file only picks existing ones.
+	if opts.PickMediaFile {
+		return
+	}
 	// Check if the media file exists before trying to launch VLC.
 	if _, err := os.Stat(opts.MediaFilePath); os.IsNotExist(err) {
 		logger.Log.Error("Media file not found", "Media File", opts.MediaFilePath)
@@ -66,18 +85,28 @@ func ValidateOptions() {
 }
 
 func Initialize(fl *cli.CLIFlags, conf *config.Config) error {
-	opts = &Options{}
+	opts = newOptions(fl)
 	steps := []step.Step{
 		{F: putVLCPath, P: conf.VLCPath},
+		{F: putMPVPath, P: conf.MPVPath},
 		{F: putDatabasePath},
 		{F: putMediaFilePath, P: fl.MediaFile.String()},
 	}
 	return step.RunSteps(steps)
 }
 
+// newOptions creates the options before any step fills them in.
+func newOptions(fl *cli.CLIFlags) *Options {
+	return &Options{PickMediaFile: fl.Continue || !fl.Show.Empty()}
+}
+
 func putVLCPath(p ...string) error {
+	if config.GetConfig().GetMediaPlayer() != config.MediaPlayerVLC {
+		return nil
+	}
+
 	optionalVLCPath := optional.FirstOrEmpty(p)
-	location, found, err := resolver.GetVLCInstallLocation(optionalVLCPath)
+	command, found, err := resolver.GetVLCInstallLocation(optionalVLCPath)
 	if err != nil {
 		logger.Log.Error("could not get VLC location", "error", err)
 		return err
@@ -90,7 +119,29 @@ func putVLCPath(p ...string) error {
 		os.Exit(1)
 	}
 
-	opts.VLCPath = location
+	opts.VLCPath = command[0]
+	opts.VLCArgs = command[1:]
+	return nil
+}
+
+func putMPVPath(p ...string) error {
+	if config.GetConfig().GetMediaPlayer() != config.MediaPlayerMPV {
+		return nil
+	}
+
+	location, found, err := resolver.GetMPVInstallLocation(optional.FirstOrEmpty(p))
+	if err != nil {
+		logger.Log.Error("could not get mpv location", "error", err)
+		return err
+	}
+
+	if !found {
+		logger.Log.Error("could not find mpv install location")
+		logger.Log.Warn("INSTALL MPV or set mpv_path in config")
+		os.Exit(1)
+	}
+
+	opts.MPVPath = location
 	return nil
 }
 
@@ -105,11 +156,85 @@ func putDatabasePath(...string) error {
 	return nil
 }
 
+// putMediaFilePath sets the file given with -file.
+// A season or show directory is accepted as well, its episodes are listed here and one is picked in SetOptions.
 func putMediaFilePath(params ...string) error {
 	if len(params) == 0 {
 		logger.Log.Error("no parameters provided for media file path location")
 		return errors.New("no parameters provided for media file path location")
 	}
 	opts.MediaFilePath = params[0]
+	if opts.MediaFilePath == "" || !fs.DirectoryExists(opts.MediaFilePath) {
+		return nil
+	}
+
+	dir, err := filepath.Abs(opts.MediaFilePath)
+	if err != nil {
+		logger.Log.Error("could not resolve media directory", "path", opts.MediaFilePath, "error", err)
+		return err
+	}
+
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && ff.IsVideoFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Log.Error("could not read media directory", "path", dir, "error", err)
+		return err
+	}
+	if len(files) == 0 {
+		logger.Log.Error("no video files found in media directory", "path", dir)
+		return fmt.Errorf("no video files found in '%s'", dir)
+	}
+
+	ff.SortEpisodes(files)
+	opts.MediaDirectoryFiles = files
 	return nil
 }
+
+// putDirectoryEpisode picks the episode to play from a directory given with -file.
+// A partially watched episode is resumed, the most recent one if there are several.
+// Otherwise the first episode that is not watched is played from the start.
+func putDirectoryEpisode(db *storage.DB) error {
+	history, err := db.GetMediaFiles()
+	if err != nil {
+		logger.Log.Error(err.Error(), "msg", "Error getting media files.")
+		return err
+	}
+
+	inDirectory := make(map[string]bool, len(opts.MediaDirectoryFiles))
+	for _, path := range opts.MediaDirectoryFiles {
+		inDirectory[path] = true
+	}
+
+	played := make(map[string]models.MediaFile)
+	for _, mf := range history {
+		if !inDirectory[mf.Filepath] {
+			continue
+		}
+		// History is ordered most recent first.
+		if !mf.Watched && mf.CurrentSecond > 0 && mf.CurrentSecond < mf.TotalSeconds {
+			opts.MediaFilePath = mf.Filepath
+			opts.MediaFileStartTime = strconv.Itoa(mf.CurrentSecond)
+			return nil
+		}
+		played[mf.Filepath] = mf
+	}
+
+	for _, path := range opts.MediaDirectoryFiles {
+		if !played[path].Watched {
+			opts.MediaFilePath = path
+			opts.MediaFileStartTime = "0"
+			return nil
+		}
+	}
+
+	logger.Log.Error("every episode in the media directory is watched, use -mark-unwatched to watch them again")
+	return fmt.Errorf("every episode in '%s' is watched", opts.MediaFilePath)
+}
@@ -0,0 +1,85 @@
+package options
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"villain-couch/agent/src/cli"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickedMediaFileSkipsHistory(t *testing.T) {
+	db := storagetest.NewDB(t)
+
+	// Without any history, the agent would exit with "No file to play" before -continue asks.
+	opts = newOptions(&cli.CLIFlags{Continue: true})
+	require.NoError(t, SetOptions(db))
+	ValidateOptions()
+	assert.Empty(t, opts.MediaFilePath)
+}
+
+func TestMediaDirectoryPicksFirstUnwatchedEpisode(t *testing.T) {
+	db := storagetest.NewDB(t)
+
+	root := t.TempDir()
+	episodes := map[string]string{}
+	for _, name := range []string{"Season 1/Show.S01E1.mkv", "Season 1/Show.S01E2.mkv", "Season 1/Show.S01E10.mkv", "Season 1/notes.txt"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		episodes[filepath.Base(name)] = path
+	}
+
+	pick := func() (string, string, error) {
+		opts = &Options{}
+		require.NoError(t, putMediaFilePath(root))
+		err := SetOptions(db)
+		return opts.MediaFilePath, opts.MediaFileStartTime, err
+	}
+
+	path, start, err := pick()
+	require.NoError(t, err)
+	assert.Equal(t, episodes["Show.S01E1.mkv"], path)
+	assert.Equal(t, "0", start)
+	assert.Len(t, opts.MediaDirectoryFiles, 3)
+	assert.Equal(t, episodes["Show.S01E10.mkv"], opts.MediaDirectoryFiles[2])
+
+	_, err = db.SetMediaFilesWatched([]string{episodes["Show.S01E1.mkv"]}, true)
+	require.NoError(t, err)
+	path, _, err = pick()
+	require.NoError(t, err)
+	assert.Equal(t, episodes["Show.S01E2.mkv"], path)
+
+	// A partially watched episode is resumed, even if an earlier one is not watched.
+	require.NoError(t, db.SetMediaFile(models.MediaFile{Filepath: episodes["Show.S01E10.mkv"], Filename: "Show.S01E10.mkv", TotalSeconds: 1200, CurrentSecond: 300}))
+	path, start, err = pick()
+	require.NoError(t, err)
+	assert.Equal(t, episodes["Show.S01E10.mkv"], path)
+	assert.Equal(t, "300", start)
+
+	_, err = db.SetMediaFilesWatched([]string{episodes["Show.S01E2.mkv"], episodes["Show.S01E10.mkv"]}, true)
+	require.NoError(t, err)
+	_, _, err = pick()
+	assert.Error(t, err)
+}
+
+func TestUnwatchedFileStartsOver(t *testing.T) {
+	db := storagetest.NewDB(t)
+
+	// Played to the credits, then marked unwatched to watch it again.
+	file := models.MediaFile{Filepath: "/tv/Dark.S01E01.mkv", Filename: "Dark.S01E01.mkv", TotalSeconds: 3000, CurrentSecond: 2950}
+	require.NoError(t, db.SetMediaFile(file))
+	require.NoError(t, db.CompleteMediaFile(file, time.Now()))
+	_, err := db.SetMediaFilesWatched([]string{file.Filepath}, false)
+	require.NoError(t, err)
+
+	opts = newOptions(&cli.CLIFlags{})
+	require.NoError(t, SetOptions(db))
+	assert.Equal(t, file.Filepath, opts.MediaFilePath)
+	assert.Equal(t, "1", opts.MediaFileStartTime)
+}
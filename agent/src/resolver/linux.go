@@ -0,0 +1,109 @@
+//go:build linux
+
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const vlcFlatpakID = "org.videolan.VLC"
+
+// Common install prefixes of distribution and source builds.
+var linuxVLCPaths = []string{
+	"/usr/bin/vlc",
+	"/usr/local/bin/vlc",
+	"/opt/vlc/bin/vlc",
+}
+
+const snapVLCPath = "/snap/bin/vlc"
+
+// Flatpak exports a launcher for every installed app, system wide and per user.
+var (
+	flatpakSystemExport = filepath.Join("/var/lib/flatpak/exports/bin", vlcFlatpakID)
+	flatpakUserExport   = filepath.Join(".local/share/flatpak/exports/bin", vlcFlatpakID)
+)
+
+func findVlcOnLinux() ([]string, bool, error) {
+	home, _ := os.UserHomeDir()
+	search := linuxSearch{root: "/", path: os.Getenv("PATH"), home: home}
+	command, found := search.find()
+	return command, found, nil
+}
+
+// linuxSearch describes where to look for VLC.
+// root is "/" except in tests, which point it at a fake filesystem.
+type linuxSearch struct {
+	root string
+	path string
+	home string
+}
+
+// find looks in $PATH, the common install prefixes, Snap and Flatpak, in that order.
+// It returns the command to launch VLC.
+func (s linuxSearch) find() ([]string, bool) {
+	if vlc, ok := s.lookPath("vlc"); ok {
+		return []string{vlc}, true
+	}
+
+	for _, p := range linuxVLCPaths {
+		if vlc := s.join(p); isExecutable(vlc) {
+			return []string{vlc}, true
+		}
+	}
+
+	if vlc := s.join(snapVLCPath); isExecutable(vlc) {
+		return []string{vlc}, true
+	}
+
+	// Flatpak apps cannot be executed directly, they have to be launched through `flatpak run`.
+	exports := []string{s.join(flatpakSystemExport)}
+	if s.home != "" {
+		exports = append(exports, s.join(s.home, flatpakUserExport))
+	}
+	for _, export := range exports {
+		if !isExecutable(export) {
+			continue
+		}
+		if flatpak, ok := s.lookPath("flatpak"); ok {
+			return []string{flatpak, "run", vlcFlatpakID}, true
+		}
+		// The exported launcher runs `flatpak run` itself.
+		return []string{export}, true
+	}
+
+	return nil, false
+}
+
+// lookPath searches $PATH like exec.LookPath, but relative to root.
+func (s linuxSearch) lookPath(name string) (string, bool) {
+	for _, dir := range filepath.SplitList(s.path) {
+		if dir == "" {
+			continue
+		}
+		if candidate := s.join(dir, name); isExecutable(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func (s linuxSearch) join(elem ...string) string {
+	return filepath.Join(append([]string{s.root}, elem...)...)
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return !info.IsDir() && info.Mode().Perm()&0111 != 0
+}
+
+func findVlcOnWindows() (string, bool, error) {
+	return "", false, nil
+}
+
+func findVlcOnDarwin() (string, bool, error) {
+	return "", false, nil
+}
@@ -0,0 +1,102 @@
+//go:build linux
+
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRoot creates the given executables below a temporary root directory.
+func fakeRoot(t *testing.T, executables ...string) string {
+	root := t.TempDir()
+	for _, e := range executables {
+		path := filepath.Join(root, e)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	}
+	return root
+}
+
+func TestLinuxSearch(t *testing.T) {
+	tests := []struct {
+		name        string
+		executables []string
+		path        string
+		expected    []string
+	}{
+		{
+			name:        "in PATH",
+			executables: []string{"/home/me/bin/vlc", "/usr/bin/vlc"},
+			path:        "/home/me/bin:/usr/bin",
+			expected:    []string{"/home/me/bin/vlc"},
+		},
+		{
+			name:        "common prefix outside PATH",
+			executables: []string{"/usr/local/bin/vlc"},
+			path:        "/bin",
+			expected:    []string{"/usr/local/bin/vlc"},
+		},
+		{
+			name:        "snap",
+			executables: []string{"/snap/bin/vlc"},
+			path:        "/usr/bin",
+			expected:    []string{"/snap/bin/vlc"},
+		},
+		{
+			name:        "system flatpak",
+			executables: []string{"/usr/bin/flatpak", "/var/lib/flatpak/exports/bin/org.videolan.VLC"},
+			path:        "/usr/bin",
+			expected:    []string{"/usr/bin/flatpak", "run", "org.videolan.VLC"},
+		},
+		{
+			name:        "user flatpak",
+			executables: []string{"/usr/bin/flatpak", "/home/me/.local/share/flatpak/exports/bin/org.videolan.VLC"},
+			path:        "/usr/bin",
+			expected:    []string{"/usr/bin/flatpak", "run", "org.videolan.VLC"},
+		},
+		{
+			name:        "flatpak export without flatpak in PATH",
+			executables: []string{"/var/lib/flatpak/exports/bin/org.videolan.VLC"},
+			path:        "/usr/bin",
+			expected:    []string{"/var/lib/flatpak/exports/bin/org.videolan.VLC"},
+		},
+		{
+			name:        "not installed",
+			executables: []string{"/usr/bin/mpv"},
+			path:        "/usr/bin",
+			expected:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := fakeRoot(t, tt.executables...)
+			search := linuxSearch{root: root, path: tt.path, home: "/home/me"}
+
+			command, found := search.find()
+
+			assert.Equal(t, tt.expected != nil, found)
+			if tt.expected == nil {
+				return
+			}
+			// The executable is found below the fake root, launcher arguments are kept as is.
+			expected := append([]string{filepath.Join(root, tt.expected[0])}, tt.expected[1:]...)
+			assert.Equal(t, expected, command)
+		})
+	}
+}
+
+func TestLinuxSearchSkipsNonExecutables(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "usr/bin/vlc")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+
+	_, found := linuxSearch{root: root, path: "/usr/bin"}.find()
+	assert.False(t, found)
+}
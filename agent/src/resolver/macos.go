@@ -37,3 +37,7 @@ func findVlcOnDarwin() (string, bool, error) {
 func findVlcOnWindows() (string, bool, error) {
 	return "", false, nil
 }
+
+func findVlcOnLinux() ([]string, bool, error) {
+	return nil, false, nil
+}
@@ -0,0 +1,32 @@
+package resolver
+
+import (
+	"os/exec"
+	"runtime"
+	"villain-couch/common/fs"
+)
+
+// GetMPVInstallLocation looks for the mpv executable.
+// A custom path takes precedence, otherwise mpv is searched in $PATH
+// and, on macOS, in the standard application bundle.
+func GetMPVInstallLocation(p string) (path string, found bool, err error) {
+	if p != "" {
+		if !fs.FileExists(p) {
+			return "", false, nil
+		}
+		return p, true, nil
+	}
+
+	if location, err := exec.LookPath("mpv"); err == nil {
+		return location, true, nil
+	}
+
+	if runtime.GOOS == "darwin" {
+		standardPath := "/Applications/mpv.app/Contents/MacOS/mpv"
+		if fs.FileExists(standardPath) {
+			return standardPath, true, nil
+		}
+	}
+
+	return "", false, nil
+}
@@ -6,26 +6,35 @@ import (
 	"villain-couch/common/fs"
 )
 
-// GetVLCInstallLocation checks for VLC in the registry.
-// It returns the installation path, a boolean indicating if it was found, and any error that occurred.
-func GetVLCInstallLocation(p string) (path string, found bool, err error) {
-	// Handle Linux and custom provided path
+// GetVLCInstallLocation looks for VLC in the platform specific locations.
+// It returns the command to launch VLC (executable followed by any launcher arguments, e.g. `flatpak run org.videolan.VLC`),
+// a boolean indicating if it was found, and any error that occurred.
+func GetVLCInstallLocation(p string) (command []string, found bool, err error) {
+	// Handle custom provided path
 	if p != "" {
 		if !fs.FileExists(p) {
-			return "", false, nil
+			return nil, false, nil
 		}
-		return p, true, nil
+		return []string{p}, true, nil
 	}
 
 	switch runtime.GOOS {
 	case "windows":
-		return findVlcOnWindows()
+		return asCommand(findVlcOnWindows())
 	case "darwin": // "darwin" is the Go identifier for macOS
-		return findVlcOnDarwin()
+		return asCommand(findVlcOnDarwin())
+	case "linux":
+		return findVlcOnLinux()
 	default:
-		// Cannot see here if
-		// For Linux or other OSes, we can add detection later.
-		// For now, it will require the config.json entry.
-		return "", false, fmt.Errorf("auto-detection not supported on %s", runtime.GOOS)
+		// For other OSes, it will require the config.json entry.
+		return nil, false, fmt.Errorf("auto-detection not supported on %s", runtime.GOOS)
 	}
 }
+
+// asCommand wraps a single executable path into a command.
+func asCommand(path string, found bool, err error) ([]string, bool, error) {
+	if !found || err != nil {
+		return nil, found, err
+	}
+	return []string{path}, true, nil
+}
@@ -52,3 +52,7 @@ func findVlcOnWindows() (path string, found bool, err error) {
 func findVlcOnDarwin() (string, bool, error) {
 	return "", false, nil
 }
+
+func findVlcOnLinux() ([]string, bool, error) {
+	return nil, false, nil
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/ff"
+	"villain-couch/common/logger"
+)
+
+// AddShowAlias records alias as another name of the canonical show and updates the aliases known to ff.
+// If canonical is an alias itself, its canonical show is used. If alias was a canonical show,
+// its aliases move over to the new canonical show.
+func (db *DB) AddShowAlias(alias, canonical string) error {
+	aliasKey, canonicalKey := ff.ShowKey(alias), ff.ShowKey(canonical)
+	if aliasKey == "" || canonicalKey == "" {
+		return errors.New("alias and show name must not be empty")
+	}
+	if aliasKey == canonicalKey {
+		return fmt.Errorf("'%s' and '%s' are already the same show", alias, canonical)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Follow canonical to the show it is an alias of, so aliases never chain.
+	existing, err := getShowAlias(tx, canonicalKey)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if existing != nil {
+		if existing.CanonicalKey == aliasKey {
+			_ = tx.Rollback()
+			return fmt.Errorf("'%s' is already an alias of '%s'", canonical, alias)
+		}
+		canonicalKey, canonical = existing.CanonicalKey, existing.Canonical
+	}
+
+	if _, err := tx.Exec(queryRepointShowAliases, canonicalKey, canonical, aliasKey); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to move aliases of '%s': %w", alias, err)
+	}
+	if _, err := tx.Exec(querySetShowAlias, aliasKey, alias, canonicalKey, canonical, time.Now()); err != nil {
+		_ = tx.Rollback()
+		logger.Log.Error("failed to add show alias", "alias", alias, "canonical", canonical, "error", err)
+		return fmt.Errorf("failed to add show alias '%s': %w", alias, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit show alias '%s': %w", alias, err)
+	}
+
+	return db.LoadShowAliases()
+}
+
+// RemoveShowAlias removes an alias and updates the aliases known to ff.
+func (db *DB) RemoveShowAlias(alias string) error {
+	res, err := db.conn.Exec(queryRemoveShowAlias, ff.ShowKey(alias))
+	if err != nil {
+		logger.Log.Error("failed to remove show alias", "alias", alias, "error", err)
+		return fmt.Errorf("failed to remove show alias '%s': %w", alias, err)
+	}
+	if err := expectAffected(res, fmt.Sprintf("show alias '%s'", alias)); err != nil {
+		return err
+	}
+	return db.LoadShowAliases()
+}
+
+// GetShowAliases returns all aliases, grouped by canonical show.
+func (db *DB) GetShowAliases() ([]models.ShowAlias, error) {
+	rows, err := db.conn.Query(queryGetShowAliases)
+	if err != nil {
+		logger.Log.Error("failed to get show aliases", "error", err)
+		return nil, fmt.Errorf("failed to get show aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := []models.ShowAlias{}
+	for rows.Next() {
+		var a models.ShowAlias
+		if err := rows.Scan(&a.AliasKey, &a.Alias, &a.CanonicalKey, &a.Canonical, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan show alias: %w", err)
+		}
+		aliases = append(aliases, a)
+	}
+	return aliases, rows.Err()
+}
+
+// LoadShowAliases hands the aliases to ff, so that show names resolve to their canonical show.
+func (db *DB) LoadShowAliases() error {
+	aliases, err := db.GetShowAliases()
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		keys[a.AliasKey] = a.CanonicalKey
+	}
+	ff.SetShowAliases(keys)
+	return nil
+}
+
+func getShowAlias(tx *sql.Tx, aliasKey string) (*models.ShowAlias, error) {
+	var a models.ShowAlias
+	err := tx.QueryRow(queryGetShowAlias, aliasKey).Scan(&a.AliasKey, &a.Alias, &a.CanonicalKey, &a.Canonical, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get show alias '%s': %w", aliasKey, err)
+	}
+	return &a, nil
+}
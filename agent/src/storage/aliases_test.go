@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/ff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowAliases(t *testing.T) {
+	db := newTestDB(t)
+	t.Cleanup(func() { ff.SetShowAliases(nil) })
+
+	require.NoError(t, db.AddShowAlias("Attack on Titan", "Shingeki no Kyojin"))
+	assert.Equal(t, ff.ShowKey("Shingeki no Kyojin"), ff.CanonicalShowKey("Attack.on.Titan"))
+	assert.True(t, ff.MatchShow("Attack on Titan", "Shingeki.no.Kyojin.S04.1080p").Matched)
+
+	// An alias of an alias points to the canonical show.
+	require.NoError(t, db.AddShowAlias("AoT", "Attack on Titan"))
+	assert.Equal(t, ff.ShowKey("Shingeki no Kyojin"), ff.CanonicalShowKey("AoT"))
+
+	// A canonical show that becomes an alias hands over its aliases.
+	require.NoError(t, db.AddShowAlias("Shingeki no Kyojin", "Attack on Titan Anime"))
+	aliases, err := db.GetShowAliases()
+	require.NoError(t, err)
+	require.Len(t, aliases, 3)
+	for _, a := range aliases {
+		assert.Equal(t, "Attack on Titan Anime", a.Canonical, a.Alias)
+	}
+	assert.Equal(t, ff.ShowKey("Attack on Titan Anime"), ff.CanonicalShowKey("AoT"))
+
+	// The reverse of an existing alias would be a loop.
+	assert.Error(t, db.AddShowAlias("Attack on Titan Anime", "AoT"))
+	assert.Error(t, db.AddShowAlias("The Office", "Office"))
+
+	require.NoError(t, db.RemoveShowAlias("aot"))
+	assert.Equal(t, ff.ShowKey("AoT"), ff.CanonicalShowKey("AoT"))
+	assert.ErrorIs(t, db.RemoveShowAlias("aot"), sql.ErrNoRows)
+}
+
+func TestFindNextLibraryEpisodeResolvesAliases(t *testing.T) {
+	db := newTestDB(t)
+	t.Cleanup(func() { ff.SetShowAliases(nil) })
+	now := time.Now()
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: "/tv", DirectoryName: "tv", CreatedAt: now, UpdatedAt: now}))
+
+	file := models.LibraryFile{
+		Filepath: "/tv/Shingeki.no.Kyojin.S04E02.mkv", Directory: "/tv", Filename: "Shingeki.no.Kyojin.S04E02.mkv", WorkspaceID: 1,
+		ShowName: "Shingeki no Kyojin", ShowKey: ff.ShowKey("Shingeki no Kyojin"), Season: 4, Episode: 2,
+	}
+	require.NoError(t, db.SetLibraryDirectory(models.LibraryDirectory{Directory: "/tv", Parent: "/", WorkspaceID: 1, ModifiedAt: now}, []models.LibraryFile{file}))
+
+	next, err := db.FindNextLibraryEpisode(ff.CanonicalShowKey("Attack on Titan"), 4, 1)
+	require.NoError(t, err)
+	assert.Nil(t, next)
+
+	// Aliases apply to files indexed before the alias was added, no rescan needed.
+	require.NoError(t, db.AddShowAlias("Shingeki no Kyojin", "Attack on Titan"))
+	next, err = db.FindNextLibraryEpisode(ff.CanonicalShowKey("Attack on Titan"), 4, 1)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.Equal(t, file.Filepath, next.Filepath)
+}
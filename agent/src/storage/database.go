@@ -3,6 +3,8 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 	"villain-couch/agent/src/models"
 	"villain-couch/common/logger"
@@ -16,10 +18,12 @@ type DB struct {
 }
 
 // NewDB initializes a connection to an SQLite database file at the given path.
-// It creates the file and the necessary table if they don't exist.
+// It creates the file if it doesn't exist and migrates the schema to the latest version.
 func NewDB(path string) (*DB, error) {
 	// sql.Open() creates the database file if it doesn't exist.
-	conn, err := sql.Open("sqlite", path)
+	// The flusher and the workspace watcher write from their own goroutines,
+	// wait for the lock instead of failing with SQLITE_BUSY.
+	conn, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		logger.Log.Error("could not open sqlite database", "error", err)
 		return nil, err
@@ -30,8 +34,9 @@ func NewDB(path string) (*DB, error) {
 		return nil, err
 	}
 
-	if _, err := conn.Exec(queryCreateTables); err != nil {
-		logger.Log.Error("could not create table", "error", err)
+	if err := migrate(conn, migrationFiles); err != nil {
+		logger.Log.Error("could not migrate database", "error", err)
+		_ = conn.Close()
 		return nil, err
 	}
 
@@ -59,19 +64,40 @@ func (db *DB) SetMediaFile(mf models.MediaFile) error {
 	return nil
 }
 
+// SetMediaFiles upserts the given media files in a single transaction.
+func (db *DB) SetMediaFiles(mfs []models.MediaFile) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	stmt, err := tx.Prepare(querySetMediaFile)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to prepare media file statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, mf := range mfs {
+		if _, err := stmt.Exec(mf.Filepath, mf.Filename, mf.TotalSeconds, mf.CurrentSecond, now, now); err != nil {
+			_ = tx.Rollback()
+			logger.Log.Error("failed to set media file for filepath", "Filepath", mf.Filepath)
+			return fmt.Errorf("failed to set media file for filepath '%s': %w", mf.Filepath, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit media files: %w", err)
+	}
+	return nil
+}
+
 // GetMediaFile retrieves a media file record by its filepath.
 // It returns sql.ErrNoRows if the filepath is not found.
 func (db *DB) GetMediaFile(filepath string) (*models.MediaFile, error) {
-	mf := &models.MediaFile{}
-
-	err := db.conn.QueryRow(queryGetMediaFile, filepath).Scan(
-		&mf.Filepath,
-		&mf.Filename,
-		&mf.TotalSeconds,
-		&mf.CurrentSecond,
-		&mf.CreatedAt,
-		&mf.UpdatedAt,
-	)
+	mf, err := scanMediaFile(db.conn.QueryRow(queryGetMediaFile, filepath))
 	if err != nil {
 		logger.Log.Error("failed to get media file for filepath", "filepath", filepath, "error", err)
 		return nil, fmt.Errorf("failed to get media file for filepath '%s': %w", filepath, err)
@@ -81,9 +107,7 @@ func (db *DB) GetMediaFile(filepath string) (*models.MediaFile, error) {
 
 // GetLatestUpdatedMediaFile retrieves the most recently updated record from the media_files table.
 func (db *DB) GetLatestUpdatedMediaFile() (*models.MediaFile, error) {
-	row := db.conn.QueryRow(queryGetLatestMediaFile)
-	var mf models.MediaFile
-	err := row.Scan(&mf.Filepath, &mf.Filename, &mf.TotalSeconds, &mf.CurrentSecond, &mf.CreatedAt, &mf.UpdatedAt)
+	mf, err := scanMediaFile(db.conn.QueryRow(queryGetLatestMediaFile))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			// This means the table is empty. It's not an application error.
@@ -91,6 +115,106 @@ func (db *DB) GetLatestUpdatedMediaFile() (*models.MediaFile, error) {
 		}
 		return nil, fmt.Errorf("failed to get latest media file: %w", err)
 	}
+	return mf, nil
+}
+
+// GetLatestUnwatchedMediaFile retrieves the most recently updated media file that was not watched to the end.
+// It returns nil if there is none.
+func (db *DB) GetLatestUnwatchedMediaFile() (*models.MediaFile, error) {
+	mf, err := scanMediaFile(db.conn.QueryRow(queryGetLatestUnwatchedMediaFile))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get latest unwatched media file: %w", err)
+	}
+	return mf, nil
+}
+
+// GetMediaFiles returns every media file, most recent first.
+func (db *DB) GetMediaFiles() ([]models.MediaFile, error) {
+	return db.queryMediaFiles(queryGetMediaFiles)
+}
+
+// GetInProgressMediaFiles returns the media files that were started but not watched to the end, most recent first.
+func (db *DB) GetInProgressMediaFiles() ([]models.MediaFile, error) {
+	return db.queryMediaFiles(queryGetInProgressMediaFiles)
+}
+
+func (db *DB) queryMediaFiles(query string) ([]models.MediaFile, error) {
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		logger.Log.Error("failed to get media files", "error", err)
+		return nil, fmt.Errorf("failed to get media files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []models.MediaFile{}
+	for rows.Next() {
+		mf, err := scanMediaFile(rows)
+		if err != nil {
+			logger.Log.Error("failed to scan media file", "error", err)
+			return nil, fmt.Errorf("failed to scan media file: %w", err)
+		}
+		files = append(files, *mf)
+	}
+	return files, rows.Err()
+}
+
+// CompleteMediaFile marks a media file watched after it was played to the end and counts the play.
+func (db *DB) CompleteMediaFile(mf models.MediaFile, at time.Time) error {
+	_, err := db.conn.Exec(queryCompleteMediaFile, mf.Filepath, mf.Filename, mf.TotalSeconds, at)
+	if err != nil {
+		logger.Log.Error("failed to complete media file", "Filepath", mf.Filepath, "error", err)
+		return fmt.Errorf("failed to complete media file '%s': %w", mf.Filepath, err)
+	}
+	return nil
+}
+
+// SetMediaFilesWatched marks the given files watched or unwatched in a single transaction.
+// It returns how many files changed. Files without a record are added when marked watched.
+func (db *DB) SetMediaFilesWatched(filepaths []string, watched bool) (int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		logger.Log.Error("failed to begin transaction", "error", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	now := time.Now()
+	changed := 0
+	for _, path := range filepaths {
+		var res sql.Result
+		if watched {
+			res, err = tx.Exec(queryMarkMediaFileWatched, path, filepath.Base(path), now)
+		} else {
+			res, err = tx.Exec(queryMarkMediaFileUnwatched, path)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			logger.Log.Error("failed to mark media file", "Filepath", path, "watched", watched, "error", err)
+			return 0, fmt.Errorf("failed to mark media file '%s': %w", path, err)
+		}
+		if n, err := res.RowsAffected(); err == nil {
+			changed += int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit watched state: %w", err)
+	}
+	return changed, nil
+}
+
+// scanMediaFile reads a media_files row selected with all its columns.
+func scanMediaFile(row interface{ Scan(...any) error }) (*models.MediaFile, error) {
+	var mf models.MediaFile
+	var watchedAt sql.NullTime
+	err := row.Scan(&mf.Filepath, &mf.Filename, &mf.TotalSeconds, &mf.CurrentSecond, &mf.CreatedAt, &mf.UpdatedAt,
+		&mf.Watched, &watchedAt, &mf.PlayCount)
+	if err != nil {
+		return nil, err
+	}
+	mf.WatchedAt = watchedAt.Time
 	return &mf, nil
 }
 
@@ -109,6 +233,7 @@ func (db *DB) InsertWorkspace(ws models.Workspace) error {
 	return nil
 }
 
+// GetWorkspaces returns all workspaces that were not removed, oldest first.
 func (db *DB) GetWorkspaces() ([]models.Workspace, error) {
 	ws := []models.Workspace{}
 	rows, err := db.conn.Query(queryGetWorkspace)
@@ -120,7 +245,7 @@ func (db *DB) GetWorkspaces() ([]models.Workspace, error) {
 
 	for rows.Next() {
 		var w models.Workspace
-		err := rows.Scan(&w.DirectoryPath, &w.DirectoryName)
+		err := rows.Scan(&w.ID, &w.DirectoryPath, &w.DirectoryName)
 		if err != nil {
 			logger.Log.Error("failed to scan ws")
 			return nil, fmt.Errorf("failed to scan ws")
@@ -135,3 +260,95 @@ func (db *DB) GetWorkspaces() ([]models.Workspace, error) {
 	}
 	return ws, nil
 }
+
+// RemoveWorkspace soft deletes a workspace by setting its deleted_at column.
+func (db *DB) RemoveWorkspace(id int) error {
+	now := time.Now()
+	res, err := db.conn.Exec(queryRemoveWorkspace, now, now, id)
+	if err != nil {
+		logger.Log.Error("failed to remove workspace", "id", id, "error", err)
+		return fmt.Errorf("failed to remove workspace %d: %w", id, err)
+	}
+	return expectAffected(res, fmt.Sprintf("workspace %d", id))
+}
+
+// RenameWorkspace changes the label (directory_name) of a workspace.
+func (db *DB) RenameWorkspace(id int, name string) error {
+	res, err := db.conn.Exec(queryRenameWorkspace, name, time.Now(), id)
+	if err != nil {
+		logger.Log.Error("failed to rename workspace", "id", id, "error", err)
+		return fmt.Errorf("failed to rename workspace %d: %w", id, err)
+	}
+	return expectAffected(res, fmt.Sprintf("workspace %d", id))
+}
+
+// expectAffected returns sql.ErrNoRows if the statement did not change any row.
+func expectAffected(res sql.Result, what string) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s not found: %w", what, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// InsertWatchSession records a finished watch session.
+func (db *DB) InsertWatchSession(ws models.WatchSession) error {
+	_, err := db.conn.Exec(queryInsertWatchSession,
+		ws.Filepath, ws.Filename, ws.StartedAt.UTC(), ws.EndedAt.UTC(),
+		ws.StartSecond, ws.EndSecond, ws.TotalSeconds, ws.WatchedSeconds, ws.Completed)
+	if err != nil {
+		logger.Log.Error("failed to insert watch session", "Filepath", ws.Filepath, "error", err)
+		return fmt.Errorf("failed to insert watch session for filepath '%s': %w", ws.Filepath, err)
+	}
+	return nil
+}
+
+// GetWatchSessions returns the most recent watch sessions matching the filter, newest first.
+func (db *DB) GetWatchSessions(filter models.WatchSessionFilter) ([]models.WatchSession, error) {
+	// "the office" matches "The.Office.S01E01.mkv" as well as ".../The Office/..."
+	show := ""
+	if words := strings.Fields(filter.Show); len(words) > 0 {
+		show = "%" + strings.Join(words, "%") + "%"
+	}
+
+	var since, until any
+	if !filter.Since.IsZero() {
+		since = filter.Since.UTC()
+	}
+	if !filter.Until.IsZero() {
+		until = filter.Until.UTC()
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1 // no limit in SQLite
+	}
+
+	rows, err := db.conn.Query(queryGetWatchSessions, show, since, until, limit)
+	if err != nil {
+		logger.Log.Error("failed to get watch sessions", "error", err)
+		return nil, fmt.Errorf("failed to get watch sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.WatchSession{}
+	for rows.Next() {
+		var ws models.WatchSession
+		err := rows.Scan(&ws.Filepath, &ws.Filename, &ws.StartedAt, &ws.EndedAt,
+			&ws.StartSecond, &ws.EndSecond, &ws.TotalSeconds, &ws.WatchedSeconds, &ws.Completed)
+		if err != nil {
+			logger.Log.Error("failed to scan watch session", "error", err)
+			return nil, fmt.Errorf("failed to scan watch session: %w", err)
+		}
+		sessions = append(sessions, ws)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Log.Error("error occurred during row iteration")
+		return nil, fmt.Errorf("error occurred during row iteration: %w", err)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDB(t *testing.T) *DB {
+	logger.Initialize(false)
+	db, err := NewDB(filepath.Join(t.TempDir(), "storage.sqlite"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestGetWatchSessionsFilters(t *testing.T) {
+	db := newTestDB(t)
+	day := time.Date(2026, 3, 10, 20, 0, 0, 0, time.Local)
+
+	sessions := []models.WatchSession{
+		{Filepath: "/tv/The Office/The.Office.S01E01.mkv", Filename: "The.Office.S01E01.mkv", StartedAt: day, EndedAt: day.Add(time.Hour)},
+		{Filepath: "/tv/The Office/The.Office.S01E02.mkv", Filename: "The.Office.S01E02.mkv", StartedAt: day.AddDate(0, 0, 1), EndedAt: day.AddDate(0, 0, 1)},
+		{Filepath: "/tv/Dark/Dark.S01E01.mkv", Filename: "Dark.S01E01.mkv", StartedAt: day.AddDate(0, 0, 2), EndedAt: day.AddDate(0, 0, 2), Completed: true},
+	}
+	for _, s := range sessions {
+		require.NoError(t, db.InsertWatchSession(s))
+	}
+
+	all, err := db.GetWatchSessions(models.WatchSessionFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "Dark.S01E01.mkv", all[0].Filename)
+	assert.True(t, all[0].Completed)
+	assert.True(t, all[2].StartedAt.Equal(day))
+
+	office, err := db.GetWatchSessions(models.WatchSessionFilter{Show: "the office"})
+	require.NoError(t, err)
+	assert.Len(t, office, 2)
+
+	ranged, err := db.GetWatchSessions(models.WatchSessionFilter{Since: day.Add(time.Hour), Until: day.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+	require.Len(t, ranged, 1)
+	assert.Equal(t, "The.Office.S01E02.mkv", ranged[0].Filename)
+
+	limited, err := db.GetWatchSessions(models.WatchSessionFilter{Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, limited, 1)
+}
+
+func TestWorkspaceSoftDelete(t *testing.T) {
+	db := newTestDB(t)
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: "/tv", DirectoryName: "tv"}))
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: "/movies", DirectoryName: "movies"}))
+
+	workspaces, err := db.GetWorkspaces()
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+	tv := workspaces[0]
+
+	require.NoError(t, db.RenameWorkspace(tv.ID, "Series"))
+	require.NoError(t, db.RemoveWorkspace(tv.ID))
+
+	workspaces, err = db.GetWorkspaces()
+	require.NoError(t, err)
+	require.Len(t, workspaces, 1)
+	assert.Equal(t, "/movies", workspaces[0].DirectoryPath)
+
+	// Removed workspaces cannot be removed or renamed again.
+	assert.ErrorIs(t, db.RemoveWorkspace(tv.ID), sql.ErrNoRows)
+	assert.ErrorIs(t, db.RenameWorkspace(tv.ID, "x"), sql.ErrNoRows)
+
+	// Adding it again restores it under the new name.
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: "/tv", DirectoryName: "tv again"}))
+	workspaces, err = db.GetWorkspaces()
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+	assert.Equal(t, tv.ID, workspaces[0].ID)
+	assert.Equal(t, "tv again", workspaces[0].DirectoryName)
+}
+
+func TestWatchedState(t *testing.T) {
+	db := newTestDB(t)
+	older := models.MediaFile{Filepath: "/tv/Dark.S01E01.mkv", Filename: "Dark.S01E01.mkv", TotalSeconds: 3000, CurrentSecond: 1200}
+	latest := models.MediaFile{Filepath: "/tv/Dark.S01E02.mkv", Filename: "Dark.S01E02.mkv", TotalSeconds: 3000, CurrentSecond: 2990}
+	require.NoError(t, db.SetMediaFile(older))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, db.SetMediaFile(latest))
+
+	finished := time.Now()
+	require.NoError(t, db.CompleteMediaFile(latest, finished))
+	require.NoError(t, db.CompleteMediaFile(latest, finished))
+	mf, err := db.GetMediaFile(latest.Filepath)
+	require.NoError(t, err)
+	assert.True(t, mf.Watched)
+	assert.Equal(t, 2, mf.PlayCount)
+	assert.True(t, mf.WatchedAt.Equal(finished))
+	assert.Equal(t, 2990, mf.CurrentSecond)
+
+	// Resuming skips the finished file.
+	resume, err := db.GetLatestUnwatchedMediaFile()
+	require.NoError(t, err)
+	require.NotNil(t, resume)
+	assert.Equal(t, older.Filepath, resume.Filepath)
+
+	changed, err := db.SetMediaFilesWatched([]string{latest.Filepath, older.Filepath}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, changed)
+	mf, err = db.GetMediaFile(latest.Filepath)
+	require.NoError(t, err)
+	assert.False(t, mf.Watched)
+	assert.True(t, mf.WatchedAt.IsZero())
+	assert.Equal(t, 2, mf.PlayCount)
+	assert.Zero(t, mf.CurrentSecond)
+
+	// Files that were never played are added, already watched ones do not count as changed.
+	changed, err = db.SetMediaFilesWatched([]string{"/tv/Dark.S01E03.mkv", older.Filepath}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, changed)
+	changed, err = db.SetMediaFilesWatched([]string{"/tv/Dark.S01E03.mkv"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, changed)
+	mf, err = db.GetMediaFile("/tv/Dark.S01E03.mkv")
+	require.NoError(t, err)
+	assert.True(t, mf.Watched)
+	assert.Equal(t, "Dark.S01E03.mkv", mf.Filename)
+	assert.Equal(t, 1, mf.PlayCount)
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"sync"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+)
+
+// Flusher writes changed cache entries to the database, so progress survives a crash.
+// It flushes on a fixed interval and whenever Trigger is called.
+// The last written value of every entry is remembered, unchanged entries are skipped.
+type Flusher struct {
+	db    *DB
+	cache *Cache[models.MediaFile]
+
+	mu    sync.Mutex
+	saved map[string]models.MediaFile
+
+	trigger chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewFlusher creates a Flusher for the given database and cache. Call Start to run it in the background.
+func NewFlusher(db *DB, cache *Cache[models.MediaFile]) *Flusher {
+	return &Flusher{
+		db:      db,
+		cache:   cache,
+		saved:   make(map[string]models.MediaFile),
+		trigger: make(chan struct{}, 1),
+	}
+}
+
+// Start flushes dirty entries every interval until Stop is called.
+// It is safe to call this function multiple times.
+func (f *Flusher) Start(interval time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.stop != nil {
+		return
+	}
+	f.stop = make(chan struct{})
+	f.done = make(chan struct{})
+
+	go f.loop(interval, f.stop, f.done)
+}
+
+// Trigger asks the background loop to flush as soon as possible. It never blocks.
+func (f *Flusher) Trigger() {
+	select {
+	case f.trigger <- struct{}{}:
+	default:
+		// A flush is already pending.
+	}
+}
+
+// Stop stops the background loop and writes any remaining dirty entries.
+func (f *Flusher) Stop() {
+	f.mu.Lock()
+	stop, done := f.stop, f.done
+	f.stop, f.done = nil, nil
+	f.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+
+	if err := f.Flush(); err != nil {
+		logger.Log.Error("could not flush media states on stop", "error", err)
+	}
+}
+
+// Flush writes every cache entry that changed since the last flush in one transaction.
+func (f *Flusher) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := f.cache.Keys()
+	present := make(map[string]bool, len(keys))
+	var dirty []models.MediaFile
+	for _, key := range keys {
+		// If empty filepath then do not add it to db
+		if key == "" {
+			continue
+		}
+		present[key] = true
+
+		val, found := f.cache.Get(key)
+		if !found {
+			continue
+		}
+		if saved, ok := f.saved[key]; ok && !isChanged(saved, val) {
+			continue
+		}
+		dirty = append(dirty, val)
+	}
+
+	// Forget entries that left the cache, they were written before being removed.
+	for key := range f.saved {
+		if !present[key] {
+			delete(f.saved, key)
+		}
+	}
+
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	if err := f.db.SetMediaFiles(dirty); err != nil {
+		return err
+	}
+
+	for _, mf := range dirty {
+		f.saved[mf.Filepath] = mf
+	}
+	logger.Log.Info("flushed media states", "count", len(dirty))
+	return nil
+}
+
+func (f *Flusher) loop(interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-f.trigger:
+		}
+
+		if err := f.Flush(); err != nil {
+			logger.Log.Error("could not flush media states", "error", err)
+		}
+	}
+}
+
+// isChanged reports whether a cache entry differs from what was last written.
+// Timestamps are ignored, they change on every tick.
+func isChanged(saved, current models.MediaFile) bool {
+	return saved.Filename != current.Filename ||
+		saved.TotalSeconds != current.TotalSeconds ||
+		saved.CurrentSecond != current.CurrentSecond
+}
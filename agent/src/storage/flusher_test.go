@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFlusher(t *testing.T) (*Flusher, *DB, *Cache[models.MediaFile]) {
+	db := newTestDB(t)
+	cache := NewCache[models.MediaFile]()
+	return NewFlusher(db, cache), db, cache
+}
+
+func TestFlusherWritesOnlyDirtyEntries(t *testing.T) {
+	flusher, db, cache := newTestFlusher(t)
+	cache.Set("/media/a.mkv", models.MediaFile{Filepath: "/media/a.mkv", Filename: "a.mkv", TotalSeconds: 100, CurrentSecond: 10})
+	cache.Set("", models.MediaFile{})
+
+	require.NoError(t, flusher.Flush())
+	first, err := db.GetMediaFile("/media/a.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, 10, first.CurrentSecond)
+
+	// Unchanged entries are not rewritten, so updated_at stays the same.
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, flusher.Flush())
+	second, err := db.GetMediaFile("/media/a.mkv")
+	require.NoError(t, err)
+	assert.True(t, first.UpdatedAt.Equal(second.UpdatedAt))
+
+	cache.Set("/media/a.mkv", models.MediaFile{Filepath: "/media/a.mkv", Filename: "a.mkv", TotalSeconds: 100, CurrentSecond: 20})
+	require.NoError(t, flusher.Flush())
+	third, err := db.GetMediaFile("/media/a.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, 20, third.CurrentSecond)
+}
+
+func TestFlusherTrigger(t *testing.T) {
+	flusher, db, cache := newTestFlusher(t)
+	flusher.Start(time.Hour)
+	defer flusher.Stop()
+
+	cache.Set("/media/a.mkv", models.MediaFile{Filepath: "/media/a.mkv", Filename: "a.mkv", TotalSeconds: 100, CurrentSecond: 42})
+	flusher.Trigger()
+
+	assert.Eventually(t, func() bool {
+		mf, err := db.GetMediaFile("/media/a.mkv")
+		return err == nil && mf.CurrentSecond == 42
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestFlusherStopFlushes(t *testing.T) {
+	flusher, db, cache := newTestFlusher(t)
+	flusher.Start(time.Hour)
+
+	cache.Set("/media/a.mkv", models.MediaFile{Filepath: "/media/a.mkv", Filename: "a.mkv", TotalSeconds: 100, CurrentSecond: 7})
+	flusher.Stop()
+
+	mf, err := db.GetMediaFile("/media/a.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, 7, mf.CurrentSecond)
+}
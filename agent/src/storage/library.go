@@ -0,0 +1,193 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+)
+
+// GetLibraryDirectory returns the indexed state of a directory, or nil if it was never scanned.
+func (db *DB) GetLibraryDirectory(directory string) (*models.LibraryDirectory, error) {
+	var d models.LibraryDirectory
+	var modifiedNs int64
+	err := db.conn.QueryRow(queryGetLibraryDirectory, directory).Scan(&d.Directory, &d.Parent, &d.WorkspaceID, &modifiedNs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to get library directory", "directory", directory, "error", err)
+		return nil, fmt.Errorf("failed to get library directory '%s': %w", directory, err)
+	}
+	d.ModifiedAt = time.Unix(0, modifiedNs)
+	return &d, nil
+}
+
+// GetLibrarySubdirectories returns the indexed direct subdirectories of a directory.
+func (db *DB) GetLibrarySubdirectories(directory string) ([]string, error) {
+	rows, err := db.conn.Query(queryGetLibrarySubdirectories, directory)
+	if err != nil {
+		logger.Log.Error("failed to get library subdirectories", "directory", directory, "error", err)
+		return nil, fmt.Errorf("failed to get library subdirectories of '%s': %w", directory, err)
+	}
+	defer rows.Close()
+
+	var dirs []string
+	for rows.Next() {
+		var dir string
+		if err := rows.Scan(&dir); err != nil {
+			return nil, fmt.Errorf("failed to scan library directory: %w", err)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, rows.Err()
+}
+
+// SetLibraryDirectory replaces the indexed files of a directory and records its mtime, in one transaction.
+// The mtime is written last, so an interrupted scan is retried the next time.
+func (db *DB) SetLibraryDirectory(dir models.LibraryDirectory, files []models.LibraryFile) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.Exec(queryDeleteLibraryFilesInDirectory, dir.Directory); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to clear library directory '%s': %w", dir.Directory, err)
+	}
+
+	now := time.Now()
+	for _, f := range files {
+		_, err := tx.Exec(queryInsertLibraryFile, f.Filepath, f.Directory, f.Filename, f.WorkspaceID, f.Size, f.ModifiedAt.UnixNano(),
+			f.ShowName, f.ShowKey, f.Season, f.Episode, f.Quality, now)
+		if err != nil {
+			_ = tx.Rollback()
+			logger.Log.Error("failed to insert library file", "filepath", f.Filepath, "error", err)
+			return fmt.Errorf("failed to insert library file '%s': %w", f.Filepath, err)
+		}
+	}
+
+	if _, err := tx.Exec(querySetLibraryDirectory, dir.Directory, dir.Parent, dir.WorkspaceID, dir.ModifiedAt.UnixNano(), now); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to set library directory '%s': %w", dir.Directory, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit library directory '%s': %w", dir.Directory, err)
+	}
+	return nil
+}
+
+// DeleteLibraryDirectory removes a directory, its subdirectories and all their files from the index.
+func (db *DB) DeleteLibraryDirectory(directory string) error {
+	prefix := directory + string(os.PathSeparator)
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.Exec(queryDeleteLibraryFilesTree, directory, prefix); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete library files below '%s': %w", directory, err)
+	}
+	if _, err := tx.Exec(queryDeleteLibraryDirectoriesTree, directory, prefix); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to delete library directories below '%s': %w", directory, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit library deletion of '%s': %w", directory, err)
+	}
+	return nil
+}
+
+// FindNextLibraryEpisode returns the first indexed episode of a show after the given season and episode.
+// It returns nil if there is none.
+func (db *DB) FindNextLibraryEpisode(showKey string, season, episode int) (*models.LibraryFile, error) {
+	f, err := scanLibraryFile(db.conn.QueryRow(queryFindNextLibraryEpisode, showKey, season, episode))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to find next library episode", "show", showKey, "error", err)
+		return nil, fmt.Errorf("failed to find next library episode of '%s': %w", showKey, err)
+	}
+	return f, nil
+}
+
+// FindPreviousLibraryEpisode returns the last indexed episode of a show before the given season and episode.
+// It returns nil if there is none.
+func (db *DB) FindPreviousLibraryEpisode(showKey string, season, episode int) (*models.LibraryFile, error) {
+	f, err := scanLibraryFile(db.conn.QueryRow(queryFindPreviousLibraryEpisode, showKey, season, episode))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Log.Error("failed to find previous library episode", "show", showKey, "error", err)
+		return nil, fmt.Errorf("failed to find previous library episode of '%s': %w", showKey, err)
+	}
+	return f, nil
+}
+
+// GetLibraryShowFiles returns the indexed files of a show in episode order.
+// A negative season returns the files of all seasons.
+func (db *DB) GetLibraryShowFiles(showKey string, season int) ([]models.LibraryFile, error) {
+	var seasonArg any
+	if season >= 0 {
+		seasonArg = season
+	}
+
+	rows, err := db.conn.Query(queryGetLibraryShowFiles, showKey, seasonArg)
+	if err != nil {
+		logger.Log.Error("failed to get library show files", "show", showKey, "error", err)
+		return nil, fmt.Errorf("failed to get library files of '%s': %w", showKey, err)
+	}
+	defer rows.Close()
+
+	var files []models.LibraryFile
+	for rows.Next() {
+		f, err := scanLibraryFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan library file: %w", err)
+		}
+		files = append(files, *f)
+	}
+	return files, rows.Err()
+}
+
+// GetLibraryShows returns the indexed shows, aliases are merged into their canonical show.
+func (db *DB) GetLibraryShows() ([]models.LibraryShow, error) {
+	rows, err := db.conn.Query(queryGetLibraryShows)
+	if err != nil {
+		logger.Log.Error("failed to get library shows", "error", err)
+		return nil, fmt.Errorf("failed to get library shows: %w", err)
+	}
+	defer rows.Close()
+
+	var shows []models.LibraryShow
+	for rows.Next() {
+		var s models.LibraryShow
+		if err := rows.Scan(&s.ShowKey, &s.ShowName, &s.Files); err != nil {
+			return nil, fmt.Errorf("failed to scan library show: %w", err)
+		}
+		shows = append(shows, s)
+	}
+	return shows, rows.Err()
+}
+
+// scanLibraryFile reads a library_files row selected with all its columns.
+func scanLibraryFile(row interface{ Scan(...any) error }) (*models.LibraryFile, error) {
+	var f models.LibraryFile
+	var modifiedNs int64
+	err := row.Scan(
+		&f.Filepath, &f.Directory, &f.Filename, &f.WorkspaceID, &f.Size, &modifiedNs,
+		&f.ShowName, &f.ShowKey, &f.Season, &f.Episode, &f.Quality,
+	)
+	if err != nil {
+		return nil, err
+	}
+	f.ModifiedAt = time.Unix(0, modifiedNs)
+	return &f, nil
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"villain-couch/common/logger"
+)
+
+// Migrations are numbered SQL files, e.g. migrations/0002_add_sessions.sql.
+// Each one is applied once, in order, and the schema version is kept in PRAGMA user_version.
+// Never edit a migration that was released, add a new one instead.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationNameRegex = regexp.MustCompile(`^(\d+)_[\w-]+\.sql$`)
+
+var ErrDatabaseTooNew = errors.New("database was created by a newer version of the agent")
+
+type migration struct {
+	Version int
+	Name    string
+	Query   string
+}
+
+// loadMigrations reads all migrations from fsys and checks that they are numbered 1..N without gaps.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []migration
+	for _, entry := range entries {
+		matches := migrationNameRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			return nil, fmt.Errorf("invalid migration file name '%s'", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		query, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration '%s': %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{Version: version, Name: entry.Name(), Query: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration '%s' is out of sequence, expected version %d", m.Name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate brings the database schema up to the latest migration in fsys.
+// Every migration runs in its own transaction together with the user_version bump,
+// so a failing migration leaves the database at the previous version.
+func migrate(conn *sql.DB, fsys fs.FS) error {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	if err := conn.QueryRow("PRAGMA user_version").Scan(&current); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	latest := len(migrations)
+	if current > latest {
+		logger.Log.Error("database schema is newer than this agent supports", "version", current, "supported", latest)
+		return fmt.Errorf("%w: schema version %d, supported %d", ErrDatabaseTooNew, current, latest)
+	}
+
+	for _, m := range migrations[current:] {
+		logger.Log.Info("applying migration", "migration", m.Name)
+		if err := applyMigration(conn, m); err != nil {
+			logger.Log.Error("could not apply migration", "migration", m.Name, "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMigration(conn *sql.DB, m migration) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration '%s': %w", m.Name, err)
+	}
+
+	if _, err := tx.Exec(m.Query); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to apply migration '%s': %w", m.Name, err)
+	}
+
+	// PRAGMA does not accept bound parameters, the version is an int so formatting is safe.
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to set schema version %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration '%s': %w", m.Name, err)
+	}
+	return nil
+}
@@ -1,3 +1,5 @@
+-- Initial schema. Uses IF NOT EXISTS because databases created before
+-- migrations were introduced already have these tables at user_version 0.
 CREATE TABLE IF NOT EXISTS media_files (
     "filepath" TEXT NOT NULL PRIMARY KEY,
     "filename" TEXT,
@@ -15,4 +17,4 @@ CREATE TABLE IF NOT EXISTS workspaces (
     created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now')),
     updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now')),
     deleted_at TEXT DEFAULT NULL
-);
\ No newline at end of file
+);
@@ -0,0 +1,17 @@
+-- One row per continuous viewing of a file.
+-- Times are stored in UTC so they compare correctly as text.
+CREATE TABLE watch_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    filepath TEXT NOT NULL,
+    filename TEXT,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME NOT NULL,
+    start_second INTEGER NOT NULL,
+    end_second INTEGER NOT NULL,
+    total_seconds INTEGER NOT NULL DEFAULT 0,
+    watched_seconds INTEGER NOT NULL DEFAULT 0,
+    completed INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_watch_sessions_started_at ON watch_sessions (started_at);
+CREATE INDEX idx_watch_sessions_filepath ON watch_sessions (filepath);
@@ -0,0 +1,29 @@
+-- Index of the video files found in the workspaces.
+-- Modification times are unix nanoseconds so they compare exactly.
+CREATE TABLE library_directories (
+    directory TEXT NOT NULL PRIMARY KEY,
+    parent TEXT NOT NULL,
+    workspace_id INTEGER NOT NULL,
+    modified_ns INTEGER NOT NULL,
+    scanned_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_library_directories_parent ON library_directories (parent);
+
+CREATE TABLE library_files (
+    filepath TEXT NOT NULL PRIMARY KEY,
+    directory TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    workspace_id INTEGER NOT NULL,
+    size INTEGER NOT NULL,
+    modified_ns INTEGER NOT NULL,
+    show_name TEXT NOT NULL DEFAULT '',
+    show_key TEXT NOT NULL DEFAULT '', -- empty when the filename could not be parsed
+    season INTEGER NOT NULL DEFAULT 0,
+    episode INTEGER NOT NULL DEFAULT 0,
+    quality INTEGER NOT NULL DEFAULT 0,
+    scanned_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_library_files_directory ON library_files (directory);
+CREATE INDEX idx_library_files_show ON library_files (show_key, season, episode);
@@ -0,0 +1,4 @@
+-- Show keys now drop articles and years ("The Office (2005)" -> "office"),
+-- forget the index so the next scan rebuilds it with the new keys.
+DELETE FROM library_files;
+DELETE FROM library_directories;
@@ -0,0 +1,11 @@
+-- Other names of a show, e.g. "Attack on Titan" for "Shingeki no Kyojin".
+-- Keys are ff.ShowKey of the names, an alias always points to a canonical show that is no alias itself.
+CREATE TABLE show_aliases (
+    alias_key TEXT PRIMARY KEY,
+    alias TEXT NOT NULL,
+    canonical_key TEXT NOT NULL,
+    canonical TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_show_aliases_canonical_key ON show_aliases (canonical_key);
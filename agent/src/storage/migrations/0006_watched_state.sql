@@ -0,0 +1,16 @@
+-- Whether a file was watched to the end, when, and how often.
+ALTER TABLE media_files ADD COLUMN watched INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE media_files ADD COLUMN watched_at DATETIME;
+ALTER TABLE media_files ADD COLUMN play_count INTEGER NOT NULL DEFAULT 0;
+
+-- Completed watch sessions are plays. Files stopped at their very end were finished before sessions were recorded.
+UPDATE media_files SET
+    play_count = (SELECT COUNT(*) FROM watch_sessions s WHERE s.filepath = media_files.filepath AND s.completed = 1),
+    watched_at = COALESCE(
+        (SELECT MAX(s.ended_at) FROM watch_sessions s WHERE s.filepath = media_files.filepath AND s.completed = 1),
+        updated_at);
+UPDATE media_files SET watched = 1 WHERE play_count > 0 OR (total_seconds > 0 AND current_second >= total_seconds);
+UPDATE media_files SET watched_at = NULL WHERE watched = 0;
+UPDATE media_files SET play_count = 1 WHERE watched = 1 AND play_count = 0;
+
+CREATE INDEX idx_media_files_watched_updated_at ON media_files (watched, updated_at);
@@ -0,0 +1,15 @@
+-- Webhook payloads waiting to be delivered, so they survive the agent exiting.
+-- A row is deleted once it was delivered or gave up after too many attempts.
+CREATE TABLE webhook_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    signature TEXT NOT NULL DEFAULT '',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_webhook_outbox_next_attempt_at ON webhook_outbox (next_attempt_at);
@@ -0,0 +1,2 @@
+-- Payloads are signed when they are sent, so a rotated secret applies to pending deliveries too.
+ALTER TABLE webhook_outbox DROP COLUMN signature;
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestConn(t *testing.T) *sql.DB {
+	logger.Initialize(false)
+	conn, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "storage.sqlite"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func schemaVersion(t *testing.T, conn *sql.DB) int {
+	var version int
+	require.NoError(t, conn.QueryRow("PRAGMA user_version").Scan(&version))
+	return version
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	conn := openTestConn(t)
+
+	require.NoError(t, migrate(conn, migrationFiles))
+
+	migrations, err := loadMigrations(migrationFiles)
+	require.NoError(t, err)
+	assert.Equal(t, len(migrations), schemaVersion(t, conn))
+
+	// Running again is a no-op.
+	require.NoError(t, migrate(conn, migrationFiles))
+	assert.Equal(t, len(migrations), schemaVersion(t, conn))
+}
+
+func TestMigrateAppliesPendingInOrder(t *testing.T) {
+	conn := openTestConn(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_a.sql": {Data: []byte("CREATE TABLE a (id INTEGER);")},
+	}
+	require.NoError(t, migrate(conn, fsys))
+	assert.Equal(t, 1, schemaVersion(t, conn))
+
+	fsys["migrations/0002_b.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE a ADD COLUMN name TEXT;")}
+	fsys["migrations/0003_c.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO a (id, name) VALUES (1, 'x');")}
+	require.NoError(t, migrate(conn, fsys))
+	assert.Equal(t, 3, schemaVersion(t, conn))
+
+	var name string
+	require.NoError(t, conn.QueryRow("SELECT name FROM a WHERE id = 1").Scan(&name))
+	assert.Equal(t, "x", name)
+}
+
+func TestMigrateRollsBackFailedMigration(t *testing.T) {
+	conn := openTestConn(t)
+	fsys := fstest.MapFS{
+		"migrations/0001_a.sql": {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"migrations/0002_b.sql": {Data: []byte("CREATE TABLE b (id INTEGER); SELECT * FROM missing;")},
+	}
+
+	assert.Error(t, migrate(conn, fsys))
+	assert.Equal(t, 1, schemaVersion(t, conn))
+
+	var count int
+	require.NoError(t, conn.QueryRow("SELECT count(*) FROM sqlite_master WHERE name = 'b'").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestMigrateRefusesNewerDatabase(t *testing.T) {
+	conn := openTestConn(t)
+	_, err := conn.Exec("PRAGMA user_version = 999")
+	require.NoError(t, err)
+
+	err = migrate(conn, migrationFiles)
+	assert.ErrorIs(t, err, ErrDatabaseTooNew)
+}
+
+func TestLoadMigrationsRejectsGaps(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0001_a.sql": {Data: []byte("SELECT 1;")},
+		"migrations/0003_c.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	_, err := loadMigrations(fsys)
+	assert.Error(t, err)
+}
+
+func TestMigrateBackfillsWatchedState(t *testing.T) {
+	conn := openTestConn(t)
+	migrations, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	require.NoError(t, err)
+	fsys := fstest.MapFS{}
+	addMigration := func(name string) {
+		data, err := fs.ReadFile(migrationFiles, name)
+		require.NoError(t, err)
+		fsys[name] = &fstest.MapFile{Data: data}
+	}
+	for _, name := range migrations {
+		if name < "migrations/0006" {
+			addMigration(name)
+		}
+	}
+	require.NoError(t, migrate(conn, fsys))
+
+	_, err = conn.Exec(`INSERT INTO media_files (filepath, total_seconds, current_second, updated_at) VALUES
+		('/tv/a.mkv', 100, 100, '2026-01-01'), ('/tv/b.mkv', 100, 50, '2026-01-01'), ('/tv/c.mkv', 100, 10, '2026-01-01')`)
+	require.NoError(t, err)
+	_, err = conn.Exec(`INSERT INTO watch_sessions (filepath, started_at, ended_at, start_second, end_second, completed) VALUES
+		('/tv/c.mkv', '2026-01-02', '2026-01-02', 0, 100, 1), ('/tv/c.mkv', '2026-01-03', '2026-01-03', 0, 100, 1)`)
+	require.NoError(t, err)
+
+	addMigration("migrations/0006_watched_state.sql")
+	require.NoError(t, migrate(conn, fsys))
+
+	rows, err := conn.Query("SELECT filepath, watched, play_count, watched_at IS NOT NULL FROM media_files ORDER BY filepath")
+	require.NoError(t, err)
+	defer rows.Close()
+	type state struct {
+		Filepath  string
+		Watched   bool
+		PlayCount int
+		HasDate   bool
+	}
+	var states []state
+	for rows.Next() {
+		var s state
+		require.NoError(t, rows.Scan(&s.Filepath, &s.Watched, &s.PlayCount, &s.HasDate))
+		states = append(states, s)
+	}
+	assert.Equal(t, []state{
+		{"/tv/a.mkv", true, 1, true},
+		{"/tv/b.mkv", false, 0, false},
+		{"/tv/c.mkv", true, 2, true},
+	}, states)
+}
@@ -2,9 +2,6 @@ package storage
 
 import _ "embed"
 
-//go:embed queries/createTables.sql
-var queryCreateTables string
-
 //go:embed queries/setMediaFile.sql
 var querySetMediaFile string
 
@@ -19,3 +16,93 @@ var querySetWorkspace string
 
 //go:embed queries/getWorkspace.sql
 var queryGetWorkspace string
+
+//go:embed queries/removeWorkspace.sql
+var queryRemoveWorkspace string
+
+//go:embed queries/renameWorkspace.sql
+var queryRenameWorkspace string
+
+//go:embed queries/insertWatchSession.sql
+var queryInsertWatchSession string
+
+//go:embed queries/getWatchSessions.sql
+var queryGetWatchSessions string
+
+//go:embed queries/getLibraryDirectory.sql
+var queryGetLibraryDirectory string
+
+//go:embed queries/getLibrarySubdirectories.sql
+var queryGetLibrarySubdirectories string
+
+//go:embed queries/setLibraryDirectory.sql
+var querySetLibraryDirectory string
+
+//go:embed queries/deleteLibraryFilesTree.sql
+var queryDeleteLibraryFilesTree string
+
+//go:embed queries/deleteLibraryDirectoriesTree.sql
+var queryDeleteLibraryDirectoriesTree string
+
+//go:embed queries/deleteLibraryFilesInDirectory.sql
+var queryDeleteLibraryFilesInDirectory string
+
+//go:embed queries/insertLibraryFile.sql
+var queryInsertLibraryFile string
+
+//go:embed queries/findNextLibraryEpisode.sql
+var queryFindNextLibraryEpisode string
+
+//go:embed queries/setShowAlias.sql
+var querySetShowAlias string
+
+//go:embed queries/repointShowAliases.sql
+var queryRepointShowAliases string
+
+//go:embed queries/getShowAlias.sql
+var queryGetShowAlias string
+
+//go:embed queries/getShowAliases.sql
+var queryGetShowAliases string
+
+//go:embed queries/removeShowAlias.sql
+var queryRemoveShowAlias string
+
+//go:embed queries/getLatestUnwatchedMediaFile.sql
+var queryGetLatestUnwatchedMediaFile string
+
+//go:embed queries/completeMediaFile.sql
+var queryCompleteMediaFile string
+
+//go:embed queries/markMediaFileWatched.sql
+var queryMarkMediaFileWatched string
+
+//go:embed queries/markMediaFileUnwatched.sql
+var queryMarkMediaFileUnwatched string
+
+//go:embed queries/getLibraryShowFiles.sql
+var queryGetLibraryShowFiles string
+
+//go:embed queries/getInProgressMediaFiles.sql
+var queryGetInProgressMediaFiles string
+
+//go:embed queries/getMediaFiles.sql
+var queryGetMediaFiles string
+
+//go:embed queries/getLibraryShows.sql
+var queryGetLibraryShows string
+
+//go:embed queries/findPreviousLibraryEpisode.sql
+var queryFindPreviousLibraryEpisode string
+
+//go:embed queries/insertWebhookDelivery.sql
+var queryInsertWebhookDelivery string
+
+//go:embed queries/getDueWebhookDeliveries.sql
+var queryGetDueWebhookDeliveries string
+
+//go:embed queries/retryWebhookDelivery.sql
+var queryRetryWebhookDelivery string
+
+//go:embed queries/deleteWebhookDelivery.sql
+var queryDeleteWebhookDelivery string
@@ -0,0 +1,9 @@
+-- A file was played to the end: mark it watched and count the play.
+-- The position is left to the regular upsert, a file that was never saved is created.
+INSERT INTO media_files (filepath, filename, total_seconds, current_second, created_at, updated_at, watched, watched_at, play_count)
+VALUES (?1, ?2, ?3, ?3, ?4, ?4, 1, ?4, 1)
+    ON CONFLICT(filepath) DO UPDATE SET
+    watched = 1,
+    watched_at = excluded.watched_at,
+    play_count = media_files.play_count + 1,
+    updated_at = excluded.updated_at;
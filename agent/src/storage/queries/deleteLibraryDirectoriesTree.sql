@@ -0,0 +1,2 @@
+-- ?1 is the directory, ?2 the directory followed by a path separator.
+DELETE FROM library_directories WHERE directory = ?1 OR substr(directory, 1, length(?2)) = ?2;
@@ -0,0 +1 @@
+DELETE FROM library_files WHERE directory = ?;
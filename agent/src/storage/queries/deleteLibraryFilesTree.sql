@@ -0,0 +1,3 @@
+-- ?1 is the directory, ?2 the directory followed by a path separator.
+-- substr is used instead of LIKE because paths may contain % and _.
+DELETE FROM library_files WHERE directory = ?1 OR substr(directory, 1, length(?2)) = ?2;
@@ -0,0 +1 @@
+DELETE FROM webhook_outbox WHERE id = ?;
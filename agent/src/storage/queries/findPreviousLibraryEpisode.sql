@@ -0,0 +1,11 @@
+-- The last episode before (?2, ?3) of show ?1 in a workspace that was not removed.
+-- ?1 is a canonical show key, files indexed under one of its aliases belong to it as well.
+-- Several copies of the same episode are ordered by quality, then workspace priority (oldest first), then path.
+SELECT f.filepath, f.directory, f.filename, f.workspace_id, f.size, f.modified_ns, f.show_name, f.show_key, f.season, f.episode, f.quality
+    FROM library_files f
+    JOIN workspaces w ON w.id = f.workspace_id AND w.deleted_at IS NULL
+    LEFT JOIN show_aliases a ON a.alias_key = f.show_key
+    WHERE COALESCE(a.canonical_key, f.show_key) = ?1
+      AND (f.season < ?2 OR (f.season = ?2 AND f.episode < ?3))
+    ORDER BY f.season DESC, f.episode DESC, f.quality DESC, f.workspace_id, f.filepath
+    LIMIT 1;
@@ -0,0 +1,6 @@
+-- The deliveries due at ?1, oldest first, at most ?2.
+SELECT id, url, event_type, payload, attempts, next_attempt_at, last_error, created_at
+    FROM webhook_outbox
+    WHERE next_attempt_at <= ?1
+    ORDER BY next_attempt_at, id
+    LIMIT ?2;
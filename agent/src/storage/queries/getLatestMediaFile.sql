@@ -1,4 +1,4 @@
-SELECT filepath, filename, total_seconds, current_second, created_at, updated_at
+SELECT filepath, filename, total_seconds, current_second, created_at, updated_at, watched, watched_at, play_count
     FROM media_files
     ORDER BY updated_at DESC
     LIMIT 1;
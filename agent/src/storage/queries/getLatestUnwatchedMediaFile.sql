@@ -0,0 +1,5 @@
+SELECT filepath, filename, total_seconds, current_second, created_at, updated_at, watched, watched_at, play_count
+    FROM media_files
+    WHERE watched = 0
+    ORDER BY updated_at DESC
+    LIMIT 1;
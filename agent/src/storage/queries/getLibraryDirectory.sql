@@ -0,0 +1 @@
+SELECT directory, parent, workspace_id, modified_ns FROM library_directories WHERE directory = ?;
@@ -0,0 +1,10 @@
+-- Files of show ?1 in a workspace that was not removed, all seasons when ?2 is NULL.
+-- ?1 is a canonical show key, files indexed under one of its aliases belong to it as well.
+-- Several copies of the same episode are ordered like in findNextLibraryEpisode.sql.
+SELECT f.filepath, f.directory, f.filename, f.workspace_id, f.size, f.modified_ns, f.show_name, f.show_key, f.season, f.episode, f.quality
+    FROM library_files f
+    JOIN workspaces w ON w.id = f.workspace_id AND w.deleted_at IS NULL
+    LEFT JOIN show_aliases a ON a.alias_key = f.show_key
+    WHERE COALESCE(a.canonical_key, f.show_key) = ?1
+      AND (?2 IS NULL OR f.season = ?2)
+    ORDER BY f.season, f.episode, f.quality DESC, f.workspace_id, f.filepath;
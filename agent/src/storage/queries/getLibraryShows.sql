@@ -0,0 +1,8 @@
+-- The shows in workspaces that were not removed, files indexed under an alias count for their canonical show.
+SELECT COALESCE(a.canonical_key, f.show_key) AS show_key, MIN(COALESCE(a.canonical, f.show_name)) AS show_name, COUNT(*)
+    FROM library_files f
+    JOIN workspaces w ON w.id = f.workspace_id AND w.deleted_at IS NULL
+    LEFT JOIN show_aliases a ON a.alias_key = f.show_key
+    WHERE f.show_key <> ''
+    GROUP BY 1
+    ORDER BY 1;
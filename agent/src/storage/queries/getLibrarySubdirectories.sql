@@ -0,0 +1 @@
+SELECT directory FROM library_directories WHERE parent = ? ORDER BY directory;
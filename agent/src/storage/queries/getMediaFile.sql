@@ -1 +1 @@
-SELECT filepath, filename, total_seconds, current_second, created_at, updated_at FROM media_files WHERE filepath = ?;
\ No newline at end of file
+SELECT filepath, filename, total_seconds, current_second, created_at, updated_at, watched, watched_at, play_count FROM media_files WHERE filepath = ?;
@@ -0,0 +1 @@
+SELECT alias_key, alias, canonical_key, canonical, created_at FROM show_aliases ORDER BY canonical_key, alias_key;
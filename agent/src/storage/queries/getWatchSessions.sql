@@ -0,0 +1,8 @@
+-- Empty show pattern and NULL dates disable the corresponding filter.
+SELECT filepath, filename, started_at, ended_at, start_second, end_second, total_seconds, watched_seconds, completed
+    FROM watch_sessions
+    WHERE (?1 = '' OR filepath LIKE ?1)
+      AND (?2 IS NULL OR started_at >= ?2)
+      AND (?3 IS NULL OR started_at < ?3)
+    ORDER BY started_at DESC
+    LIMIT ?4;
@@ -1 +1 @@
-SELECT directory_path, directory_name FROM workspaces;
+SELECT id, directory_path, directory_name FROM workspaces WHERE deleted_at IS NULL ORDER BY id;
@@ -0,0 +1,14 @@
+INSERT INTO library_files (filepath, directory, filename, workspace_id, size, modified_ns, show_name, show_key, season, episode, quality, scanned_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    ON CONFLICT(filepath) DO UPDATE SET
+    directory = excluded.directory,
+    filename = excluded.filename,
+    workspace_id = excluded.workspace_id,
+    size = excluded.size,
+    modified_ns = excluded.modified_ns,
+    show_name = excluded.show_name,
+    show_key = excluded.show_key,
+    season = excluded.season,
+    episode = excluded.episode,
+    quality = excluded.quality,
+    scanned_at = excluded.scanned_at;
@@ -0,0 +1,2 @@
+INSERT INTO watch_sessions (filepath, filename, started_at, ended_at, start_second, end_second, total_seconds, watched_seconds, completed)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);
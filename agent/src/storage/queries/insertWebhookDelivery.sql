@@ -0,0 +1,2 @@
+INSERT INTO webhook_outbox (url, event_type, payload, next_attempt_at, created_at)
+VALUES (?, ?, ?, ?, ?);
@@ -0,0 +1,3 @@
+-- Marks a file unwatched, the play count is history and stays.
+-- The position is reset, otherwise a file played to the end would resume at the credits.
+UPDATE media_files SET watched = 0, watched_at = NULL, current_second = 0 WHERE filepath = ? AND watched = 1;
@@ -0,0 +1,9 @@
+-- Marks a file watched by hand. It counts as played once, unless it was played before.
+-- Files that are already watched are left alone, so they do not count as changed.
+INSERT INTO media_files (filepath, filename, total_seconds, current_second, created_at, updated_at, watched, watched_at, play_count)
+VALUES (?1, ?2, 0, 0, ?3, ?3, 1, ?3, 1)
+    ON CONFLICT(filepath) DO UPDATE SET
+    watched = 1,
+    watched_at = excluded.watched_at,
+    play_count = MAX(media_files.play_count, 1)
+    WHERE media_files.watched = 0;
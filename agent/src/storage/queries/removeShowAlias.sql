@@ -0,0 +1 @@
+DELETE FROM show_aliases WHERE alias_key = ?;
@@ -0,0 +1 @@
+UPDATE workspaces SET directory_name = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- A canonical show that becomes an alias hands its aliases over to the new canonical show.
+UPDATE show_aliases SET canonical_key = ?1, canonical = ?2 WHERE canonical_key = ?3;
@@ -0,0 +1,7 @@
+INSERT INTO library_directories (directory, parent, workspace_id, modified_ns, scanned_at)
+VALUES (?, ?, ?, ?, ?)
+    ON CONFLICT(directory) DO UPDATE SET
+    parent = excluded.parent,
+    workspace_id = excluded.workspace_id,
+    modified_ns = excluded.modified_ns,
+    scanned_at = excluded.scanned_at;
@@ -0,0 +1,5 @@
+INSERT INTO show_aliases (alias_key, alias, canonical_key, canonical, created_at)
+VALUES (?, ?, ?, ?, ?) ON CONFLICT(alias_key) DO UPDATE SET
+    alias = excluded.alias,
+    canonical_key = excluded.canonical_key,
+    canonical = excluded.canonical;
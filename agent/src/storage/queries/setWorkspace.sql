@@ -1,2 +1,6 @@
+-- Adding a previously removed workspace brings it back.
 INSERT INTO workspaces (directory_path, directory_name, created_at, updated_at)
-VALUES (?, ?, ?, ?) ON CONFLICT(directory_path) DO NOTHING
\ No newline at end of file
+VALUES (?, ?, ?, ?) ON CONFLICT(directory_path) DO UPDATE SET
+    directory_name = CASE WHEN deleted_at IS NULL THEN directory_name ELSE excluded.directory_name END,
+    updated_at = CASE WHEN deleted_at IS NULL THEN updated_at ELSE excluded.updated_at END,
+    deleted_at = NULL
@@ -19,14 +19,29 @@ func GetCache() *Cache[models.MediaFile] {
 	return cache
 }
 
+// Flusher
+var flusher *Flusher
+
+func GetFlusher() *Flusher {
+	return flusher
+}
+
 func Initialize(DatabaseFilePath string) error {
 	var err error
 	cache = NewCache[models.MediaFile]()
 	db, err = NewDB(DatabaseFilePath)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := db.LoadShowAliases(); err != nil {
+		return err
+	}
+	flusher = NewFlusher(db, cache)
+	return nil
 }
 
 func Shutdown() {
+	flusher.Stop()
 	if err := db.Close(); err != nil {
 		logger.Log.Error("could not close database", "error", err.Error())
 		return
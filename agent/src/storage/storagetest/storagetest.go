@@ -0,0 +1,25 @@
+// Package storagetest opens throwaway databases for tests.
+//
+//	db := storagetest.NewDB(t)
+//	require.NoError(t, db.SetMediaFile(file))
+package storagetest
+
+import (
+	"path/filepath"
+	"testing"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/logger"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NewDB opens a migrated database in a temporary directory, closed when the test ends.
+// It initializes the logger, the storage functions log through it.
+func NewDB(t testing.TB) *storage.DB {
+	t.Helper()
+	logger.Initialize(false)
+	db, err := storage.NewDB(filepath.Join(t.TempDir(), "storage.sqlite"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/common/logger"
+)
+
+// InsertWebhookDelivery adds a payload to the webhook outbox, due right away.
+func (db *DB) InsertWebhookDelivery(d models.WebhookDelivery) error {
+	now := time.Now().UTC()
+	_, err := db.conn.Exec(queryInsertWebhookDelivery, d.URL, d.EventType, string(d.Payload), now, now)
+	if err != nil {
+		logger.Log.Error("failed to insert webhook delivery", "url", d.URL, "event", d.EventType, "error", err)
+		return fmt.Errorf("failed to insert webhook delivery for '%s': %w", d.URL, err)
+	}
+	return nil
+}
+
+// GetDueWebhookDeliveries returns up to limit deliveries due at the given time, oldest first.
+func (db *DB) GetDueWebhookDeliveries(at time.Time, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := db.conn.Query(queryGetDueWebhookDeliveries, at.UTC(), limit)
+	if err != nil {
+		logger.Log.Error("failed to get due webhook deliveries", "error", err)
+		return nil, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload string
+		err := rows.Scan(&d.ID, &d.URL, &d.EventType, &payload, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.Payload = []byte(payload)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RetryWebhookDelivery records a failed attempt and when to try again.
+func (db *DB) RetryWebhookDelivery(id, attempts int, next time.Time, lastError string) error {
+	if _, err := db.conn.Exec(queryRetryWebhookDelivery, attempts, next.UTC(), lastError, id); err != nil {
+		logger.Log.Error("failed to reschedule webhook delivery", "id", id, "error", err)
+		return fmt.Errorf("failed to reschedule webhook delivery %d: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteWebhookDelivery removes a delivery from the outbox.
+func (db *DB) DeleteWebhookDelivery(id int) error {
+	if _, err := db.conn.Exec(queryDeleteWebhookDelivery, id); err != nil {
+		logger.Log.Error("failed to delete webhook delivery", "id", id, "error", err)
+		return fmt.Errorf("failed to delete webhook delivery %d: %w", id, err)
+	}
+	return nil
+}
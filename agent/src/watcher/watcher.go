@@ -0,0 +1,241 @@
+// Package watcher keeps the library index up to date while the agent runs,
+// by watching the workspace directories for created, renamed and deleted files.
+package watcher
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"villain-couch/agent/src/library"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher rescans the directories of the workspaces that changed.
+//
+// Changes are collected until nothing happened for the debounce period, so a burst like a
+// season download finishing results in a single rescan. A steady stream of changes is still
+// flushed every maxWait.
+type Watcher struct {
+	fsw      *fsnotify.Watcher
+	scanner  *library.Scanner
+	debounce time.Duration
+	maxWait  time.Duration
+
+	roots   []models.Workspace
+	pending map[string]int // directory -> workspace id
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New(db *storage.DB, debounce time.Duration) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		fsw:      fsw,
+		scanner:  library.NewScanner(db),
+		debounce: debounce,
+		maxWait:  10 * debounce,
+		pending:  make(map[string]int),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start watches every directory of the given workspaces.
+// A workspace that cannot be watched (e.g. an unplugged drive) is skipped.
+func (w *Watcher) Start(workspaces []models.Workspace) {
+	for _, ws := range workspaces {
+		root := filepath.Clean(ws.DirectoryPath)
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		ws.DirectoryPath = root
+		if err := w.addTree(root); err != nil {
+			logger.Log.Warn("could not watch workspace", "workspace", root, "error", err)
+			continue
+		}
+		w.roots = append(w.roots, ws)
+	}
+
+	w.wg.Add(1)
+	go w.loop()
+}
+
+// Close stops watching. Changes that are still waiting for the debounce period are scanned first.
+func (w *Watcher) Close() {
+	close(w.done)
+	w.wg.Wait()
+	if err := w.fsw.Close(); err != nil {
+		logger.Log.Warn("could not close workspace watcher", "error", err)
+	}
+}
+
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+
+	// quiet fires after the debounce period without changes, deadline after maxWait since the first change.
+	var quiet, deadline <-chan time.Time
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if !w.handle(event) {
+				continue
+			}
+			quiet = time.After(w.debounce)
+			if deadline == nil {
+				deadline = time.After(w.maxWait)
+			}
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			// An overflow drops events, so the index may be behind. The next -scan or -find-next catches up.
+			logger.Log.Warn("workspace watcher error", "error", err)
+
+		case <-quiet:
+			w.flush()
+			quiet, deadline = nil, nil
+
+		case <-deadline:
+			w.flush()
+			quiet, deadline = nil, nil
+
+		case <-w.done:
+			w.flush()
+			return
+		}
+	}
+}
+
+// handle records the directory of a changed entry for the next flush.
+// It returns false for events that do not change the index.
+func (w *Watcher) handle(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+
+	path := filepath.Clean(event.Name)
+	ws, ok := w.workspaceOf(path)
+	if !ok {
+		return false
+	}
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			// Watch the new directory and everything that was already moved into it.
+			if err := w.addTree(path); err != nil {
+				logger.Log.Warn("could not watch directory", "directory", path, "error", err)
+			}
+		}
+	}
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		w.removeTree(path)
+	}
+
+	dir := filepath.Dir(path)
+	if path == ws.DirectoryPath {
+		// The workspace root itself went away, keep its index like an unplugged drive.
+		return false
+	}
+	w.pending[dir] = ws.ID
+	return true
+}
+
+// flush rescans the directories that changed.
+func (w *Watcher) flush() {
+	if len(w.pending) == 0 {
+		return
+	}
+
+	dirs := make([]string, 0, len(w.pending))
+	for dir := range w.pending {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		wsID := w.pending[dir]
+		delete(w.pending, dir)
+
+		stats, err := w.scanner.ScanDirectory(wsID, w.existingAncestor(dir))
+		if err != nil {
+			logger.Log.Warn("could not rescan changed directory", "directory", dir, "error", err)
+			continue
+		}
+		logger.Log.Info("rescanned changed directory", "directory", dir, "files", stats.Files, "removed", stats.RemovedDirs)
+	}
+}
+
+// existingAncestor returns dir, or its closest parent that still exists when dir was removed
+// together with its contents. It does not go above the workspace root.
+func (w *Watcher) existingAncestor(dir string) string {
+	ws, _ := w.workspaceOf(dir)
+	for dir != ws.DirectoryPath {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// workspaceOf returns the workspace that contains path.
+// When workspaces are nested, the innermost one wins.
+func (w *Watcher) workspaceOf(path string) (models.Workspace, bool) {
+	var found models.Workspace
+	ok := false
+	for _, ws := range w.roots {
+		if isWithin(path, ws.DirectoryPath) && (!ok || len(ws.DirectoryPath) > len(found.DirectoryPath)) {
+			found, ok = ws, true
+		}
+	}
+	return found, ok
+}
+
+// addTree watches dir and all of its subdirectories, inotify watches are not recursive.
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			logger.Log.Warn("could not read directory", "directory", path, "error", err)
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.fsw.Add(path); err != nil {
+			logger.Log.Warn("could not watch directory", "directory", path, "error", err)
+		}
+		return nil
+	})
+}
+
+// removeTree stops watching a directory that was removed or renamed, and its subdirectories.
+// Renamed directories come back with a Create event under their new name.
+func (w *Watcher) removeTree(dir string) {
+	for _, watched := range w.fsw.WatchList() {
+		if isWithin(watched, dir) {
+			_ = w.fsw.Remove(watched)
+		}
+	}
+}
+
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
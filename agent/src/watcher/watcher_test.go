@@ -0,0 +1,92 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"villain-couch/agent/src/library"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupWatcher(t *testing.T) (*storage.DB, string) {
+	db := storagetest.NewDB(t)
+
+	root := t.TempDir()
+	now := time.Now()
+	require.NoError(t, db.InsertWorkspace(models.Workspace{DirectoryPath: root, DirectoryName: "tv", CreatedAt: now, UpdatedAt: now}))
+	workspaces, err := db.GetWorkspaces()
+	require.NoError(t, err)
+	_, err = library.NewScanner(db).ScanAll()
+	require.NoError(t, err)
+
+	w, err := New(db, 50*time.Millisecond)
+	require.NoError(t, err)
+	w.Start(workspaces)
+	t.Cleanup(w.Close)
+	return db, root
+}
+
+func nextEpisode(t *testing.T, db *storage.DB, showKey string, season, episode int) string {
+	next, err := db.FindNextLibraryEpisode(showKey, season, episode)
+	require.NoError(t, err)
+	if next == nil {
+		return ""
+	}
+	return next.Filepath
+}
+
+func TestWatcherIndexesNewFilesInNewDirectories(t *testing.T) {
+	db, root := setupWatcher(t)
+
+	// A season download lands as a new directory with several files.
+	season := filepath.Join(root, "Dark", "Season 1")
+	require.NoError(t, os.MkdirAll(season, 0o755))
+	for _, name := range []string{"Dark.S01E01.mkv", "Dark.S01E02.mkv"} {
+		require.NoError(t, os.WriteFile(filepath.Join(season, name), []byte("video"), 0o644))
+	}
+
+	want := filepath.Join(season, "Dark.S01E02.mkv")
+	require.Eventually(t, func() bool { return nextEpisode(t, db, "dark", 1, 1) == want }, 5*time.Second, 20*time.Millisecond)
+
+	// Files dropped later into the already watched directory are picked up as well.
+	later := filepath.Join(season, "Dark.S01E03.mkv")
+	require.NoError(t, os.WriteFile(later, []byte("video"), 0o644))
+	require.Eventually(t, func() bool { return nextEpisode(t, db, "dark", 1, 2) == later }, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestWatcherHandlesRenameAndDelete(t *testing.T) {
+	db, root := setupWatcher(t)
+
+	file := filepath.Join(root, "Dark.S01E02.mkv")
+	require.NoError(t, os.WriteFile(file, []byte("video"), 0o644))
+	require.Eventually(t, func() bool { return nextEpisode(t, db, "dark", 1, 1) == file }, 5*time.Second, 20*time.Millisecond)
+
+	renamed := filepath.Join(root, "Dark.S01E05.mkv")
+	require.NoError(t, os.Rename(file, renamed))
+	require.Eventually(t, func() bool { return nextEpisode(t, db, "dark", 1, 1) == renamed }, 5*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, os.Remove(renamed))
+	require.Eventually(t, func() bool { return nextEpisode(t, db, "dark", 1, 1) == "" }, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestWorkspaceOfPrefersInnermostWorkspace(t *testing.T) {
+	p := filepath.FromSlash
+	w := &Watcher{roots: []models.Workspace{{ID: 1, DirectoryPath: p("/tv")}, {ID: 2, DirectoryPath: p("/tv/anime")}}}
+
+	assert.Equal(t, 2, mustWorkspace(t, w, p("/tv/anime/show")).ID)
+	assert.Equal(t, 1, mustWorkspace(t, w, p("/tv/animals")).ID)
+	_, ok := w.workspaceOf(p("/movies/x.mkv"))
+	assert.False(t, ok)
+}
+
+func mustWorkspace(t *testing.T, w *Watcher, path string) models.Workspace {
+	ws, ok := w.workspaceOf(path)
+	require.True(t, ok)
+	return ws
+}
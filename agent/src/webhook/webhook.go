@@ -0,0 +1,301 @@
+// Package webhook notifies configured HTTP endpoints about playback events.
+//
+// Payloads are written to an outbox table first and delivered in the background,
+// so a slow or unreachable endpoint never blocks the tick loop and nothing is lost when the agent exits.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage"
+	"villain-couch/common/logger"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body, for webhooks with a secret.
+	SignatureHeader = "X-Villain-Couch-Signature"
+	EventHeader     = "X-Villain-Couch-Event"
+	DeliveryHeader  = "X-Villain-Couch-Delivery"
+)
+
+const (
+	// maxAttempts is how often a delivery is tried before it is dropped.
+	maxAttempts = 10
+	// maxRetryDelay caps the exponential backoff between attempts.
+	maxRetryDelay = time.Hour
+	// batchSize is how many deliveries are sent in one go.
+	batchSize = 20
+	// queueSize is how many events may wait to be written to the outbox.
+	queueSize = 256
+	// closeTimeout is how long Close keeps delivering, e.g. the exited event, before it gives up.
+	closeTimeout = 3 * time.Second
+)
+
+// hook is a configured webhook with its parsed payload template.
+type hook struct {
+	models.Webhook
+	payload *models.WebhookPayload
+}
+
+// Dispatcher writes the events wanted by the webhooks to the outbox and delivers them.
+type Dispatcher struct {
+	db         *storage.DB
+	hooks      []hook
+	client     *http.Client
+	retryDelay time.Duration // delay after the first failed attempt, doubled after every further one
+	// pollInterval is how often the outbox is checked for deliveries due for a retry.
+	pollInterval time.Duration
+	closeTimeout time.Duration
+
+	events chan models.PlaybackEvent
+	stop   chan struct{} // closed by Close, the loop delivers what is due one last time
+	ctx    context.Context
+	cancel context.CancelFunc // aborts deliveries in flight
+	wg     sync.WaitGroup
+}
+
+// New creates a dispatcher for the webhooks, which were validated with the config.
+func New(db *storage.DB, webhooks []models.Webhook) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		db:           db,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		retryDelay:   5 * time.Second,
+		pollInterval: 5 * time.Second,
+		closeTimeout: closeTimeout,
+		events:       make(chan models.PlaybackEvent, queueSize),
+		stop:         make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+	for _, w := range webhooks {
+		payload, err := w.NewPayload()
+		if err != nil {
+			logger.Log.Error("invalid webhook template, skipping webhook", "url", w.URL, "error", err)
+			continue
+		}
+		d.hooks = append(d.hooks, hook{Webhook: w, payload: payload})
+	}
+	return d
+}
+
+// Start delivers in the background, beginning with what is left in the outbox from the last run.
+func (d *Dispatcher) Start() {
+	d.wg.Add(1)
+	go d.loop()
+}
+
+// Handle is the subscriber of the event bus. It never blocks, if the queue is full the event is dropped.
+func (d *Dispatcher) Handle(e models.PlaybackEvent) {
+	if !d.wanted(e.Type) {
+		return
+	}
+	select {
+	case d.events <- e:
+	default:
+		logger.Log.Warn("webhook queue is full, dropping event", "type", e.Type)
+	}
+}
+
+// Close writes the queued events to the outbox and delivers what is due, so the exited event
+// is not left for the next start. After closeTimeout the deliveries in flight are aborted,
+// whatever is left is delivered on the next start.
+func (d *Dispatcher) Close() {
+	close(d.stop)
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	timer := time.NewTimer(d.closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-finished:
+	case <-timer.C:
+		logger.Log.Warn("webhooks are taking too long, delivering the rest on the next start")
+		d.cancel()
+		<-finished
+	}
+	d.cancel()
+}
+
+func (d *Dispatcher) wanted(eventType string) bool {
+	for _, h := range d.hooks {
+		if h.Wants(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the configured webhook with url, deliveries to a url that is no longer configured are dropped.
+func (d *Dispatcher) find(url string) (hook, bool) {
+	for _, h := range d.hooks {
+		if h.URL == url {
+			return h, true
+		}
+	}
+	return hook{}, false
+}
+
+func (d *Dispatcher) loop() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	d.deliverDue()
+	for {
+		select {
+		case e := <-d.events:
+			d.enqueue(e)
+			d.deliverDue()
+		case <-ticker.C:
+			d.deliverDue()
+		case <-d.stop:
+			for {
+				select {
+				case e := <-d.events:
+					d.enqueue(e)
+				default:
+					d.deliverDue()
+					return
+				}
+			}
+		}
+	}
+}
+
+// enqueue writes a delivery to the outbox for every webhook that wants the event.
+func (d *Dispatcher) enqueue(e models.PlaybackEvent) {
+	for _, h := range d.hooks {
+		if !h.Wants(e.Type) {
+			continue
+		}
+		payload, err := h.payload.Render(e)
+		if err != nil {
+			logger.Log.Error("could not render webhook payload", "url", h.URL, "type", e.Type, "error", err)
+			continue
+		}
+		// Signed when it is sent, so a new secret applies to what is still pending. Logged by the storage.
+		_ = d.db.InsertWebhookDelivery(models.WebhookDelivery{URL: h.URL, EventType: e.Type, Payload: payload})
+	}
+}
+
+// deliverDue sends the deliveries that are due, until none is left or delivering is aborted.
+// Every webhook gets its own goroutine, so an endpoint that times out does not hold up the others.
+// The deliveries of a webhook are sent in order, after a failure the rest wait for the next round.
+func (d *Dispatcher) deliverDue() {
+	for d.ctx.Err() == nil {
+		deliveries, err := d.db.GetDueWebhookDeliveries(time.Now(), batchSize)
+		if err != nil || len(deliveries) == 0 {
+			return
+		}
+
+		var urls []string
+		byURL := make(map[string][]models.WebhookDelivery)
+		for _, delivery := range deliveries {
+			if _, ok := d.find(delivery.URL); !ok {
+				// E.g. removed because its secret leaked, it must not get anything anymore.
+				logger.Log.Warn("dropping delivery for a webhook that is no longer configured", "url", delivery.URL, "type", delivery.EventType)
+				_ = d.db.DeleteWebhookDelivery(delivery.ID)
+				continue
+			}
+			if _, ok := byURL[delivery.URL]; !ok {
+				urls = append(urls, delivery.URL)
+			}
+			byURL[delivery.URL] = append(byURL[delivery.URL], delivery)
+		}
+
+		var wg sync.WaitGroup
+		var failed atomic.Bool
+		for _, url := range urls {
+			wg.Add(1)
+			go func(queue []models.WebhookDelivery) {
+				defer wg.Done()
+				for _, delivery := range queue {
+					if d.ctx.Err() != nil || !d.deliver(delivery) {
+						failed.Store(true)
+						return
+					}
+				}
+			}(byURL[url])
+		}
+		wg.Wait()
+
+		// The deliveries left behind by a failed webhook are still due, fetching them again would not stop.
+		if len(deliveries) < batchSize || failed.Load() {
+			return
+		}
+	}
+}
+
+// deliver sends a delivery and reports whether it arrived. A failed one is retried later, until maxAttempts.
+func (d *Dispatcher) deliver(delivery models.WebhookDelivery) bool {
+	err := d.send(delivery)
+	if err == nil {
+		logger.Log.Info("webhook delivered", "url", delivery.URL, "type", delivery.EventType)
+		_ = d.db.DeleteWebhookDelivery(delivery.ID)
+		return true
+	}
+	if d.ctx.Err() != nil {
+		// Interrupted by Close, the attempt does not count.
+		return false
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts >= maxAttempts {
+		logger.Log.Error("giving up on webhook", "url", delivery.URL, "type", delivery.EventType, "attempts", attempts, "error", err)
+		_ = d.db.DeleteWebhookDelivery(delivery.ID)
+		return false
+	}
+
+	delay := min(d.retryDelay<<(attempts-1), maxRetryDelay)
+	logger.Log.Warn("webhook failed, retrying", "url", delivery.URL, "type", delivery.EventType, "attempt", attempts, "in", delay, "error", err)
+	_ = d.db.RetryWebhookDelivery(delivery.ID, attempts, time.Now().Add(delay), err.Error())
+	return false
+}
+
+func (d *Dispatcher) send(delivery models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "villain-couch")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	if h, _ := d.find(delivery.URL); h.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(h.Secret, delivery.Payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of payload with secret, as sent in SignatureHeader.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,227 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"villain-couch/agent/src/models"
+	"villain-couch/agent/src/storage/storagetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver records the requests it gets and fails the first failures of them.
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []request
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, request{header: req.Header.Clone(), body: body})
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (r *receiver) received() []request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]request(nil), r.requests...)
+}
+
+func TestDispatcherDeliversSignedEvents(t *testing.T) {
+	db := storagetest.NewDB(t)
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	d := New(db, []models.Webhook{{URL: server.URL, Events: []string{models.EventCompleted}, Secret: "secret"}})
+	d.Start()
+	t.Cleanup(d.Close)
+
+	d.Handle(models.NewPlaybackEvent(models.EventProgress, "/media/a.mkv", "a.mkv", 10, 100, time.Now()))
+	d.Handle(models.NewPlaybackEvent(models.EventCompleted, "/media/a.mkv", "a.mkv", 99, 100, time.Now()))
+
+	require.Eventually(t, func() bool { return len(recv.received()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	got := recv.received()[0]
+	assert.Equal(t, models.EventCompleted, got.header.Get(EventHeader))
+	assert.Equal(t, "sha256="+Sign("secret", got.body), got.header.Get(SignatureHeader))
+	assert.Contains(t, string(got.body), `"type":"completed"`)
+
+	require.Eventually(t, func() bool {
+		due, err := db.GetDueWebhookDeliveries(time.Now().Add(time.Hour), 10)
+		return err == nil && len(due) == 0
+	}, 2*time.Second, 10*time.Millisecond, "delivered payloads leave the outbox")
+}
+
+func TestDispatcherSignsWithTheCurrentSecret(t *testing.T) {
+	db := storagetest.NewDB(t)
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	// Left in the outbox by the last run, the secret was rotated since.
+	require.NoError(t, db.InsertWebhookDelivery(models.WebhookDelivery{URL: server.URL, EventType: models.EventStarted, Payload: []byte(`{}`)}))
+
+	d := New(db, []models.Webhook{{URL: server.URL, Events: []string{models.EventStarted}, Secret: "rotated"}})
+	d.Start()
+	d.Close()
+
+	require.Len(t, recv.received(), 1)
+	assert.Equal(t, "sha256="+Sign("rotated", []byte(`{}`)), recv.received()[0].header.Get(SignatureHeader))
+}
+
+func TestDispatcherRetriesFailedDeliveries(t *testing.T) {
+	db := storagetest.NewDB(t)
+	recv := &receiver{failures: 2}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	d := New(db, []models.Webhook{{URL: server.URL, Events: []string{models.EventStarted}}})
+	d.retryDelay = 10 * time.Millisecond
+	d.pollInterval = 10 * time.Millisecond
+	d.Start()
+	t.Cleanup(d.Close)
+
+	d.Handle(models.NewPlaybackEvent(models.EventStarted, "/media/a.mkv", "a.mkv", 0, 100, time.Now()))
+
+	require.Eventually(t, func() bool { return len(recv.received()) == 3 }, 2*time.Second, 10*time.Millisecond)
+	requests := recv.received()
+	assert.Equal(t, requests[0].body, requests[2].body)
+	assert.Empty(t, requests[0].header.Get(SignatureHeader))
+}
+
+func TestDispatcherKeepsUndeliveredEvents(t *testing.T) {
+	db := storagetest.NewDB(t)
+	recv := &receiver{failures: 1}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+	hooks := []models.Webhook{{URL: server.URL, Events: []string{models.EventExited}}}
+
+	// The first attempt fails and the agent exits before the retry is due.
+	d := New(db, hooks)
+	d.retryDelay = time.Hour
+	d.Start()
+	d.Handle(models.NewPlaybackEvent(models.EventExited, "/media/a.mkv", "a.mkv", 50, 100, time.Now()))
+	require.Eventually(t, func() bool { return len(recv.received()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	d.Close()
+
+	due, err := db.GetDueWebhookDeliveries(time.Now().Add(maxRetryDelay), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.NotEmpty(t, due[0].LastError)
+
+	// The next run delivers it once it is due.
+	require.NoError(t, db.RetryWebhookDelivery(due[0].ID, due[0].Attempts, time.Now(), due[0].LastError))
+	d = New(db, hooks)
+	d.Start()
+	t.Cleanup(d.Close)
+	require.Eventually(t, func() bool { return len(recv.received()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Contains(t, string(recv.received()[1].body), `"type":"exited"`)
+}
+
+func TestDispatcherDeliversOnClose(t *testing.T) {
+	db := storagetest.NewDB(t)
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	d := New(db, []models.Webhook{{URL: server.URL, Events: []string{models.EventExited}}})
+	d.Start()
+	d.Handle(models.NewPlaybackEvent(models.EventExited, "/media/a.mkv", "a.mkv", 50, 100, time.Now()))
+	d.Close()
+
+	// The agent exits right after, the event must not wait for the next start.
+	require.Len(t, recv.received(), 1)
+	assert.Equal(t, models.EventExited, recv.received()[0].header.Get(EventHeader))
+}
+
+func TestDispatcherCloseGivesUpOnSlowWebhooks(t *testing.T) {
+	db := storagetest.NewDB(t)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	d := New(db, []models.Webhook{{URL: server.URL, Events: []string{models.EventExited}}})
+	d.closeTimeout = 50 * time.Millisecond
+	d.Start()
+	d.Handle(models.NewPlaybackEvent(models.EventExited, "/media/a.mkv", "a.mkv", 50, 100, time.Now()))
+	started := time.Now()
+	d.Close()
+
+	assert.Less(t, time.Since(started), 2*time.Second)
+	due, err := db.GetDueWebhookDeliveries(time.Now(), 10)
+	require.NoError(t, err)
+	require.Len(t, due, 1, "the aborted delivery is sent on the next start")
+	assert.Equal(t, 0, due[0].Attempts)
+}
+
+func TestDispatcherDeliversPastAHangingWebhook(t *testing.T) {
+	db := storagetest.NewDB(t)
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(hanging.Close)
+	t.Cleanup(func() { close(release) })
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	d := New(db, []models.Webhook{
+		{URL: hanging.URL, Events: []string{models.EventStarted}},
+		{URL: server.URL, Events: []string{models.EventStarted}},
+	})
+	d.closeTimeout = 50 * time.Millisecond
+	d.Start()
+	t.Cleanup(d.Close)
+
+	// The hanging webhook comes first and would hold the other one up until the client times out.
+	d.Handle(models.NewPlaybackEvent(models.EventStarted, "/media/a.mkv", "a.mkv", 0, 100, time.Now()))
+	require.Eventually(t, func() bool { return len(recv.received()) == 1 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestDispatcherDropsDeliveriesOfRemovedWebhooks(t *testing.T) {
+	db := storagetest.NewDB(t)
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	t.Cleanup(server.Close)
+
+	// Left in the outbox by a webhook that was removed from the config since.
+	require.NoError(t, db.InsertWebhookDelivery(models.WebhookDelivery{URL: server.URL + "/removed", EventType: models.EventStarted, Payload: []byte(`{}`)}))
+
+	d := New(db, []models.Webhook{{URL: server.URL, Events: []string{models.EventStarted}}})
+	d.Start()
+	d.Close()
+
+	assert.Empty(t, recv.received())
+	due, err := db.GetDueWebhookDeliveries(time.Now().Add(maxRetryDelay), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+}
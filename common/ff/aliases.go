@@ -0,0 +1,28 @@
+package ff
+
+import "sync/atomic"
+
+var showAliases atomic.Pointer[map[string]string]
+
+// SetShowAliases sets the known show aliases.
+// The map is keyed by the ShowKey of an alias and holds the ShowKey of its canonical show,
+// e.g. "attackontitan" -> "shingekinokyojin".
+func SetShowAliases(aliases map[string]string) {
+	showAliases.Store(&aliases)
+}
+
+// ResolveShowKey returns the key of the canonical show for a ShowKey, or the key itself if it is no alias.
+func ResolveShowKey(key string) string {
+	if aliases := showAliases.Load(); aliases != nil {
+		if canonical, ok := (*aliases)[key]; ok {
+			return canonical
+		}
+	}
+	return key
+}
+
+// CanonicalShowKey returns the ShowKey of the canonical show of a show or directory name,
+// so that all names of a show compare equal.
+func CanonicalShowKey(showName string) string {
+	return ResolveShowKey(ShowKey(showName))
+}
@@ -67,79 +67,101 @@ import (
 	"villain-couch/common/logger"
 )
 
-// Enhanced regex to capture Show Name (Group 1), Season (Group 3), and Episode (Group 5).
-var episodeRegex = regexp.MustCompile(`^(.*?)[\._ ]?(S|s)?(\d{1,2})(E|e|X|x)(\d{1,2})`)
 var bracketRegex = regexp.MustCompile(`\[.*?\]`)
+var qualityRegex = regexp.MustCompile(`(?i)(\d{3,4})[pi]\b`)
 
-// ParseEpisodeInfo attempts to parse a filename into an EpisodeInfo struct.
-func ParseEpisodeInfo(filePath string) (EpisodeInfo, error) {
-	filename := filepath.Base(filePath)
-	matches := episodeRegex.FindStringSubmatch(filename)
-
-	if len(matches) < 6 {
-		return EpisodeInfo{}, fmt.Errorf("could not parse episode info from: %s", filename)
-	}
+// videoExtensions are the file extensions treated as playable episodes.
+var videoExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".m4v": true, ".avi": true, ".mov": true, ".wmv": true,
+	".webm": true, ".ts": true, ".m2ts": true, ".flv": true, ".mpg": true, ".mpeg": true, ".ogv": true,
+}
 
-	showName := matches[1]
-	seasonStr := matches[3]
-	episodeStr := matches[5]
+// IsVideoFile reports whether the file has a known video extension.
+func IsVideoFile(filePath string) bool {
+	return videoExtensions[strings.ToLower(filepath.Ext(filePath))]
+}
 
-	season, err := strconv.Atoi(seasonStr)
-	if err != nil {
-		return EpisodeInfo{}, fmt.Errorf("could not parse season number '%s'", seasonStr)
-	}
+// ShowKey returns the normalized show name used to compare shows, e.g. "The.Office.(US)" -> "officeus".
+func ShowKey(showName string) string {
+	return strings.Join(ShowTokens(showName), "")
+}
 
-	episode, err := strconv.Atoi(episodeStr)
-	if err != nil {
-		return EpisodeInfo{}, fmt.Errorf("could not parse episode number '%s'", episodeStr)
+// VideoQuality returns the vertical resolution found in a filename (e.g. 1080 for "1080p"), or 0.
+func VideoQuality(filePath string) int {
+	matches := qualityRegex.FindStringSubmatch(filepath.Base(filePath))
+	if matches == nil {
+		return 0
 	}
-
-	showName = strings.ReplaceAll(showName, ".", " ")
-	showName = strings.ReplaceAll(showName, "_", " ")
-	showName = strings.TrimSpace(showName)
-
-	return EpisodeInfo{
-		ShowName: showName,
-		Season:   season,
-		Episode:  episode,
-		FilePath: filePath,
-	}, nil
+	quality, _ := strconv.Atoi(matches[1])
+	return quality
 }
 
 // FindNextEpisode searches through a list of all found files to find the next episode.
 // This version has been corrected with more robust logic.
 func FindNextEpisode(targetInfo EpisodeInfo, allFiles map[string][]string) (EpisodeInfo, bool) {
-	var potentialEpisodes []EpisodeInfo
-	normalizedTargetName := normalizeString(targetInfo.ShowName)
+	// Map iteration order is random, go through the directories sorted to stay deterministic.
+	dirs := make([]string, 0, len(allFiles))
+	for dir := range allFiles {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
 
-	for _, files := range allFiles {
-		for _, file := range files {
-			info, err := ParseEpisodeInfo(file)
-			if err != nil {
-				continue
-			}
+	var files []string
+	for _, dir := range dirs {
+		files = append(files, allFiles[dir]...)
+	}
+	return FindNextEpisodeInFiles(targetInfo, files)
+}
 
-			// Add any episode from the same show to our list of candidates.
-			if normalizeString(info.ShowName) == normalizedTargetName {
-				potentialEpisodes = append(potentialEpisodes, info)
-			}
+// FindNextEpisodeInFiles finds the next episode in a list of files ordered by priority.
+// When several files hold the same episode, the one with the highest video quality wins,
+// then the one that comes first in files, then the one with the smallest path.
+func FindNextEpisodeInFiles(targetInfo EpisodeInfo, files []string) (EpisodeInfo, bool) {
+	type candidate struct {
+		info     EpisodeInfo
+		quality  int
+		priority int
+	}
+
+	var potentialEpisodes []candidate
+
+	for i, file := range files {
+		info, err := ParseEpisodeInfo(file)
+		if err != nil {
+			continue
+		}
+
+		// Add any episode from the same show to our list of candidates.
+		if MatchShow(targetInfo.ShowName, info.ShowName).Matched {
+			potentialEpisodes = append(potentialEpisodes, candidate{info: info, quality: VideoQuality(file), priority: i})
 		}
 	}
 
-	// Sort all found episodes for the show chronologically.
+	// Sort all found episodes for the show chronologically, best copy of every episode first.
 	sort.Slice(potentialEpisodes, func(i, j int) bool {
-		if potentialEpisodes[i].Season != potentialEpisodes[j].Season {
-			return potentialEpisodes[i].Season < potentialEpisodes[j].Season
+		a, b := potentialEpisodes[i], potentialEpisodes[j]
+		if a.info.Season != b.info.Season {
+			return a.info.Season < b.info.Season
+		}
+		if a.info.Episode != b.info.Episode {
+			return a.info.Episode < b.info.Episode
+		}
+		if a.quality != b.quality {
+			return a.quality > b.quality
+		}
+		if a.priority != b.priority {
+			return a.priority < b.priority
 		}
-		return potentialEpisodes[i].Episode < potentialEpisodes[j].Episode
+		return a.info.FilePath < b.info.FilePath
 	})
 
 	// --- CORRECTED LOGIC ---
 	// Instead of looking FOR the target episode in the list, we now look for the
 	// FIRST episode in the sorted list that comes chronologically AFTER the target.
-	for _, candidate := range potentialEpisodes {
+	for _, c := range potentialEpisodes {
+		candidate := c.info
 		isLaterSeason := candidate.Season > targetInfo.Season
-		isLaterEpisodeInSameSeason := candidate.Season == targetInfo.Season && candidate.Episode > targetInfo.Episode
+		isLaterEpisodeInSameSeason := candidate.Season == targetInfo.Season && candidate.Episode > targetInfo.LastEpisode()
 
 		if isLaterSeason || isLaterEpisodeInSameSeason {
 			// Because the list is sorted, the first candidate that meets this
@@ -152,56 +174,77 @@ func FindNextEpisode(targetInfo EpisodeInfo, allFiles map[string][]string) (Epis
 	return EpisodeInfo{}, false
 }
 
-// --- Functions from previous version (normalizeString, FindRelatedFiles) ---
-func normalizeString(s string) string {
-	s = strings.ToLower(s)
-	s = bracketRegex.ReplaceAllString(s, "")
-	s = strings.ReplaceAll(s, ".", "")
-	s = strings.ReplaceAll(s, "_", "")
-	s = strings.ReplaceAll(s, " ", "")
-	return strings.TrimSpace(s)
+// SortEpisodes sorts files by their parsed season and episode instead of their names,
+// so "Show.S01E10" comes after "Show.S01E9". Files that are no episode come last, by path.
+func SortEpisodes(files []string) {
+	type entry struct {
+		path string
+		info EpisodeInfo
+		ok   bool
+	}
+
+	entries := make([]entry, len(files))
+	for i, file := range files {
+		info, err := ParseEpisodeInfo(file)
+		entries[i] = entry{path: file, info: info, ok: err == nil}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ok != b.ok {
+			return a.ok
+		}
+		if a.ok && a.info.Season != b.info.Season {
+			return a.info.Season < b.info.Season
+		}
+		if a.ok && a.info.Episode != b.info.Episode {
+			return a.info.Episode < b.info.Episode
+		}
+		return a.path < b.path
+	})
+
+	for i, e := range entries {
+		files[i] = e.path
+	}
 }
 
+// FindRelatedFiles returns the files of every directory in baseDir that matches the show of targetFilename,
+// see MatchShow.
 func FindRelatedFiles(baseDir, targetFilename string) (map[string][]string, error) {
 	targetInfo, err := ParseEpisodeInfo(targetFilename)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse target filename: %w", err)
 	}
-	normalizedKey := normalizeString(targetInfo.ShowName)
 
-	logger.Log.Info("extracted", "show name", targetInfo.ShowName, "normalized search key", normalizedKey)
+	logger.Log.Info("extracted", "show name", targetInfo.ShowName, "search tokens", ShowTokens(targetInfo.ShowName))
 
-	results := make(map[string][]string)
-	entries, err := os.ReadDir(baseDir)
+	matches, err := RankDirectories(baseDir, targetInfo.ShowName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read base directory %s: %w", baseDir, err)
+		return nil, err
 	}
-	logger.Log.Info("entries", "size", len(entries), "directory", baseDir)
-	for _, entry := range entries {
-		if !entry.IsDir() {
+	logger.Log.Info("entries", "size", len(matches), "directory", baseDir)
+
+	results := make(map[string][]string)
+	for _, m := range matches {
+		if !m.Matched {
 			continue
 		}
-		dirName := entry.Name()
-		normalizedDirName := normalizeString(dirName)
-		if strings.Contains(normalizedDirName, normalizedKey) {
-			matchedDirPath := filepath.Join(baseDir, dirName)
-			logger.Log.Info("found matchings", "directory", matchedDirPath)
-			var filesInDir []string
-			err := filepath.WalkDir(matchedDirPath, func(path string, d os.DirEntry, err error) error {
-				if err != nil {
-					return err
-				}
-				if !d.IsDir() {
-					filesInDir = append(filesInDir, path)
-				}
-				return nil
-			})
+		logger.Log.Info("found matchings", "directory", m.Name, "score", m.Score)
+		var filesInDir []string
+		err := filepath.WalkDir(m.Name, func(path string, d os.DirEntry, err error) error {
 			if err != nil {
-				logger.Log.Warn("could not read files", "directory", matchedDirPath, "error", err)
-				continue
+				return err
 			}
-			results[matchedDirPath] = filesInDir
+			if !d.IsDir() {
+				filesInDir = append(filesInDir, path)
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Log.Warn("could not read files", "directory", m.Name, "error", err)
+			continue
 		}
+		results[m.Name] = filesInDir
 	}
 	return results, nil
 }
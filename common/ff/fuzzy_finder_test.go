@@ -1,9 +1,58 @@
 package ff
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestValidateAndUnwrapErrors tests that our validate function works as expected
 // and that we can correctly iterate through the joined errors.
 func TestGetNextEpisodeFilename(t *testing.T) {}
+
+// createTree creates empty files below root.
+func createTree(t *testing.T, root string, files ...string) {
+	for _, f := range files {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+}
+
+func TestFindNextEpisodeInFilesTieBreak(t *testing.T) {
+	target, err := ParseEpisodeInfo("Show.S01E01.720p.mkv")
+	require.NoError(t, err)
+
+	files := []string{
+		"/ssd/Show/Show.S01E02.720p.mkv",
+		"/nas/Show/Show.S01E02.1080p.mkv",
+		"/usb/Show/Show.S01E02.1080p.mkv",
+		"/ssd/Show/Show.S01E03.2160p.mkv",
+	}
+
+	next, found := FindNextEpisodeInFiles(target, files)
+	require.True(t, found)
+	// Best quality wins, then the earlier (higher priority) file.
+	assert.Equal(t, "/nas/Show/Show.S01E02.1080p.mkv", next.FilePath)
+}
+
+func TestSortEpisodes(t *testing.T) {
+	files := []string{
+		"/tv/Show/Season 2/Show.S02E01.mkv",
+		"/tv/Show/extras/Behind the Scenes.mkv",
+		"/tv/Show/Season 1/Show.S01E10.mkv",
+		"/tv/Show/Season 1/Show.S01E9.mkv",
+		"/tv/Show/Season 1/Show.S01E01.mkv",
+	}
+	SortEpisodes(files)
+	assert.Equal(t, []string{
+		"/tv/Show/Season 1/Show.S01E01.mkv",
+		"/tv/Show/Season 1/Show.S01E9.mkv",
+		"/tv/Show/Season 1/Show.S01E10.mkv",
+		"/tv/Show/Season 2/Show.S02E01.mkv",
+		"/tv/Show/extras/Behind the Scenes.mkv",
+	}, files)
+}
@@ -0,0 +1,210 @@
+package ff
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultMatchThreshold is the score a directory needs to be related to a show.
+const DefaultMatchThreshold = 0.8
+
+var (
+	tokenSplitRegex = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	yearTokenRegex  = regexp.MustCompile(`^(?:19|20)\d{2}$`)
+	// stopTokenRegex marks where the show name in a directory name ends, e.g. "Show.S01.1080p" or "Show Season 2".
+	stopTokenRegex = regexp.MustCompile(`^(?:s\d{1,3}(?:e\d{1,4})?|\d{1,2}x\d{2,3}|season|series|complete|\d{3,4}[pi])$`)
+)
+
+var articles = map[string]bool{"the": true, "a": true, "an": true}
+
+// tokenSimilarity is how similar two tokens must be to count as the same word, e.g. a typo.
+const tokenSimilarity = 0.8
+
+var matchThreshold atomic.Value
+
+// SetMatchThreshold sets the score between 0 and 1 a directory needs to be related to a show.
+func SetMatchThreshold(threshold float64) {
+	matchThreshold.Store(threshold)
+}
+
+// MatchThreshold returns the configured threshold, or DefaultMatchThreshold.
+func MatchThreshold() float64 {
+	if t, ok := matchThreshold.Load().(float64); ok && t > 0 {
+		return t
+	}
+	return DefaultMatchThreshold
+}
+
+// ShowTokens splits a show or directory name into comparable words.
+// Articles and years are dropped, and a directory name ends at its season or quality tags.
+// "The.Office.(US).S01.1080p" -> [office us]
+func ShowTokens(name string) []string {
+	name = strings.ToLower(bracketRegex.ReplaceAllString(name, " "))
+
+	var tokens []string
+	for _, t := range tokenSplitRegex.Split(name, -1) {
+		if t == "" {
+			continue
+		}
+		if stopTokenRegex.MatchString(t) && len(tokens) > 0 {
+			break
+		}
+		tokens = append(tokens, t)
+	}
+
+	var kept []string
+	for _, t := range tokens {
+		if !articles[t] && !yearTokenRegex.MatchString(t) {
+			kept = append(kept, t)
+		}
+	}
+	// "1923" or "The The" are names on their own.
+	if len(kept) == 0 {
+		return tokens
+	}
+	return kept
+}
+
+// ShowMatch explains how well a name matches a show.
+type ShowMatch struct {
+	Name      string
+	Tokens    []string
+	Score     float64
+	Matched   bool
+	Missing   []string // show tokens not found in the name
+	Extra     []string // name tokens that are not part of the show
+	Threshold float64
+	Alias     bool // matched because both names belong to the same show alias
+}
+
+// Reason describes the verdict in a sentence, for debugging.
+func (m ShowMatch) Reason() string {
+	switch {
+	case len(m.Tokens) == 0:
+		return "no words left after dropping articles, years and tags"
+	case m.Alias:
+		return "alias of the same show"
+	case m.Matched:
+		return fmt.Sprintf("score %.2f >= %.2f", m.Score, m.Threshold)
+	}
+
+	reason := fmt.Sprintf("score %.2f < %.2f", m.Score, m.Threshold)
+	if len(m.Missing) > 0 {
+		reason += fmt.Sprintf(", missing %s", strings.Join(m.Missing, " "))
+	}
+	if len(m.Extra) > 0 {
+		reason += fmt.Sprintf(", extra %s", strings.Join(m.Extra, " "))
+	}
+	return reason
+}
+
+// MatchShow scores how well name (e.g. a directory) matches a show name.
+//
+// The score is the best of two measures between 0 and 1:
+// the share of words both names have in common, allowing small typos,
+// and the edit-distance similarity of the names with their words joined.
+// Every word counts, so "Dark" does not match "Dark Matter".
+// Names that are aliases of the same show (see SetShowAliases) always match.
+func MatchShow(show, name string) ShowMatch {
+	showTokens, nameTokens := ShowTokens(show), ShowTokens(name)
+	m := ShowMatch{Name: name, Tokens: nameTokens, Threshold: MatchThreshold()}
+	if len(showTokens) == 0 || len(nameTokens) == 0 {
+		return m
+	}
+
+	// Different names of the same show, e.g. "Attack on Titan" and "Shingeki no Kyojin".
+	showKey, nameKey := strings.Join(showTokens, ""), strings.Join(nameTokens, "")
+	if showKey != nameKey && ResolveShowKey(showKey) == ResolveShowKey(nameKey) {
+		m.Score, m.Matched, m.Alias = 1, true, true
+		return m
+	}
+
+	used := make([]bool, len(nameTokens))
+	common := 0
+	for _, st := range showTokens {
+		found := false
+		for i, nt := range nameTokens {
+			if !used[i] && similarity(st, nt) >= tokenSimilarity {
+				used[i], found = true, true
+				common++
+				break
+			}
+		}
+		if !found {
+			m.Missing = append(m.Missing, st)
+		}
+	}
+	for i, nt := range nameTokens {
+		if !used[i] {
+			m.Extra = append(m.Extra, nt)
+		}
+	}
+
+	overlap := 2 * float64(common) / float64(len(showTokens)+len(nameTokens))
+	joined := similarity(strings.Join(showTokens, ""), strings.Join(nameTokens, ""))
+	m.Score = math.Max(overlap, joined)
+	m.Matched = m.Score >= m.Threshold
+	return m
+}
+
+// RankDirectories scores every directory in baseDir against a show name, best match first.
+func RankDirectories(baseDir, show string) ([]ShowMatch, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base directory %s: %w", baseDir, err)
+	}
+
+	var matches []ShowMatch
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		m := MatchShow(show, entry.Name())
+		m.Name = filepath.Join(baseDir, entry.Name())
+		matches = append(matches, m)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches, nil
+}
+
+// similarity returns 1 minus the edit distance of a and b relative to the longer one.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,93 @@
+package ff
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowTokens(t *testing.T) {
+	tests := map[string][]string{
+		"The Office (US)":                         {"office", "us"},
+		"The.Office.US.S01.1080p.WEB":             {"office", "us"},
+		"Doctor Who (2005) Season 1":              {"doctor", "who"},
+		"[SubsPlease] Jujutsu Kaisen [1080p]":     {"jujutsu", "kaisen"},
+		"1923":                                    {"1923"},
+		"The Bear S02 COMPLETE":                   {"bear"},
+		"Shingeki no Kyojin - The Final Season":   {"shingeki", "no", "kyojin", "final"},
+		"Star.Trek.The.Next.Generation.1x01-1x02": {"star", "trek", "next", "generation"},
+		"Amélie": {"amélie"},
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, ShowTokens(name), name)
+	}
+}
+
+func TestMatchShow(t *testing.T) {
+	tests := []struct {
+		show, dir string
+		matched   bool
+	}{
+		{"The Office (US)", "Office US", true},
+		{"The Office US", "The.Office.US.S01-S09.1080p.BluRay", true},
+		{"Dark", "Dark", true},
+		{"Dark", "Dark Matter", false},
+		{"Dark", "Dark.S01.1080p.NF.WEB", true},
+		{"Doctor Who", "Doctor.Who.2005.S13", true},
+		{"Breaking Bad", "Breakng Bad", true}, // typo
+		{"Better Call Saul", "Breaking Bad", false},
+		{"Show", "Other Show", false},
+		{"Succession", "Succesion S04", true},
+	}
+	for _, tt := range tests {
+		m := MatchShow(tt.show, tt.dir)
+		assert.Equal(t, tt.matched, m.Matched, "%s vs %s: %s", tt.show, tt.dir, m.Reason())
+	}
+
+	m := MatchShow("Dark", "Dark Matter")
+	assert.Equal(t, []string{"matter"}, m.Extra)
+	assert.Contains(t, m.Reason(), "extra matter")
+}
+
+func TestMatchThreshold(t *testing.T) {
+	t.Cleanup(func() { SetMatchThreshold(0) })
+
+	assert.False(t, MatchShow("Dark", "Dark Matter").Matched)
+	SetMatchThreshold(0.6)
+	assert.True(t, MatchShow("Dark", "Dark Matter").Matched)
+}
+
+func TestRankDirectories(t *testing.T) {
+	root := t.TempDir()
+	createTree(t, root, "Dark Matter/x.mkv", "Dark/x.mkv", "Dark.S02.720p/x.mkv", "Darkwing Duck/x.mkv", "file.mkv")
+
+	matches, err := RankDirectories(root, "Dark")
+	require.NoError(t, err)
+	require.Len(t, matches, 4)
+
+	var names []string
+	for _, m := range matches {
+		names = append(names, filepath.Base(m.Name))
+	}
+	assert.Equal(t, []string{"Dark", "Dark.S02.720p", "Dark Matter", "Darkwing Duck"}, names)
+	assert.True(t, matches[1].Matched)
+	assert.False(t, matches[2].Matched)
+}
+
+func TestMatchShowAliases(t *testing.T) {
+	SetShowAliases(map[string]string{"attackontitan": "shingekinokyojin", "aot": "shingekinokyojin"})
+	t.Cleanup(func() { SetShowAliases(nil) })
+
+	m := MatchShow("Attack on Titan", "Shingeki.no.Kyojin.S04.1080p")
+	assert.True(t, m.Matched)
+	assert.True(t, m.Alias)
+	assert.Equal(t, "alias of the same show", m.Reason())
+
+	// Two aliases of the same show match each other.
+	assert.True(t, MatchShow("AoT", "Attack on Titan").Matched)
+	assert.False(t, MatchShow("Attack on Titan", "Dark").Matched)
+	assert.Equal(t, "shingekinokyojin", CanonicalShowKey("The Attack on Titan (2013)"))
+	assert.Equal(t, "dark", CanonicalShowKey("Dark"))
+}
@@ -1,9 +1,28 @@
 package ff
 
+import "time"
+
 // EpisodeInfo holds structured data parsed from a media filename.
 type EpisodeInfo struct {
 	ShowName string
 	Season   int
 	Episode  int
-	FilePath string // Keep track of the original file path
+	// EpisodeEnd is the last episode of a multi-episode file (S01E01-E03 -> 3), Episode otherwise.
+	EpisodeEnd int
+	// AbsoluteEpisode is the episode number counted over all seasons, as used by anime releases.
+	AbsoluteEpisode int
+	// AirDate is set for date-based shows.
+	AirDate      time.Time
+	Year         int
+	Resolution   int // vertical resolution, e.g. 1080
+	ReleaseGroup string
+	FilePath     string // Keep track of the original file path
+}
+
+// LastEpisode returns the last episode contained in the file.
+func (e EpisodeInfo) LastEpisode() int {
+	if e.EpisodeEnd > e.Episode {
+		return e.EpisodeEnd
+	}
+	return e.Episode
 }
@@ -0,0 +1,207 @@
+package ff
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// S01E02, S01E01E02, S01E01-E03, S01E01-03, S01.E02, S00E05, S01E100
+	seasonEpisodeRegex = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])S(\d{1,3})[ ._-]?E(\d{1,4})((?:[ ._-]*E\d{1,4}|-\d{1,4}\b)*)`)
+	// 1x02, 01x02, 1x02-1x03
+	crossRegex = regexp.MustCompile(`(?i)(?:^|[ ._\-\[(])(\d{1,2})x(\d{2,3})((?:-(?:\d{1,2}x)?\d{2,3})*)(?:[ ._\-\])]|$)`)
+	// 2024.03.15, 2024-03-15, 2024 03 15
+	airDateRegex = regexp.MustCompile(`(?:^|[ ._\-\[(])((?:19|20)\d{2})[ ._-](\d{2})[ ._-](\d{2})(?:[ ._\-\])]|$)`)
+	// [Group] Show - 137 [1080p], Show - 137v2
+	absoluteRegex = regexp.MustCompile(`^(.*?)\s+-\s+(\d{1,4})(?:v\d)?(?:\s|\[|\(|$)`)
+	// E05, Ep05, Episode 5 in a season folder
+	bareEpisodeRegex = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:E|Ep|Episode)[ ._-]?(\d{1,4})\b`)
+
+	// Show.S02.1080p, Show Season 2
+	showSeasonDirRegex = regexp.MustCompile(`(?i)^(.*?)[ ._-]+(?:S|Season[ ._-]*)(\d{1,3})\b`)
+
+	leadingGroupRegex  = regexp.MustCompile(`^\[([^\]]+)\]\s*`)
+	trailingGroupRegex = regexp.MustCompile(`-([A-Za-z0-9]*[A-Za-z][A-Za-z0-9]*)$`)
+	trailingTagsRegex  = regexp.MustCompile(`(?:\s*\[[^\]]*\])+$`)
+	showYearRegex      = regexp.MustCompile(`^(.+?)[ ._]+\(?((?:19|20)\d{2})\)?$`)
+)
+
+// SeasonDirRegex matches season folders like "Season 2", "season_02", "Series 2" or "S02", capturing the number.
+var SeasonDirRegex = regexp.MustCompile(`(?i)^(?:season|series|s)[ ._-]*(\d{1,3})$`)
+
+// notReleaseGroups are hyphenated source tags that end some names without a group, e.g. "WEB-DL".
+var notReleaseGroups = map[string]bool{"dl": true, "rip": true, "ray": true}
+
+// subtitleExtensions are stripped like video extensions, so subtitles parse like their video.
+var subtitleExtensions = map[string]bool{".srt": true, ".ass": true, ".ssa": true, ".sub": true, ".idx": true, ".vtt": true}
+
+// ParseEpisodeInfo attempts to parse a filename into an EpisodeInfo struct.
+//
+// The user defined patterns (see SetCustomPatterns) are tried first, then the built-in ones.
+// It understands, in this order:
+//   - S01E02, including multi-episode files (S01E01E02, S01E01-E03) and specials (S00E05)
+//   - 1x02
+//   - air dates (2024.03.15), Season is the year and Episode is MMDD so that episodes still sort chronologically
+//   - anime absolute numbering ("[Group] Show - 137 [1080p]"), reported as season 1
+//   - bare episode numbers (E05) in a season folder, the season and show come from the folders
+func ParseEpisodeInfo(filePath string) (EpisodeInfo, error) {
+	filename := filepath.Base(filePath)
+	name := stripExtension(filename)
+
+	info := EpisodeInfo{FilePath: filePath, Resolution: VideoQuality(filename)}
+	if m := leadingGroupRegex.FindStringSubmatch(name); m != nil {
+		info.ReleaseGroup = m[1]
+		name = name[len(m[0]):]
+	}
+
+	var tail string
+	switch {
+	case parseCustom(filePath, name, &info, &tail):
+	case parseSeasonEpisode(name, &info, &tail):
+	case parseCross(name, &info, &tail):
+	case parseAirDate(name, &info, &tail):
+	case parseAbsolute(name, &info, &tail):
+	case parseBareEpisode(filePath, name, &info, &tail):
+	default:
+		return EpisodeInfo{}, fmt.Errorf("could not parse episode info from: %s", filename)
+	}
+
+	if info.ReleaseGroup == "" {
+		tail = trailingTagsRegex.ReplaceAllString(tail, "")
+		if m := trailingGroupRegex.FindStringSubmatch(tail); m != nil && !notReleaseGroups[strings.ToLower(m[1])] {
+			info.ReleaseGroup = m[1]
+		}
+	}
+	return info, nil
+}
+
+func parseSeasonEpisode(name string, info *EpisodeInfo, tail *string) bool {
+	loc := seasonEpisodeRegex.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return false
+	}
+	info.Season = atoi(name[loc[2]:loc[3]])
+	info.Episode = atoi(name[loc[4]:loc[5]])
+	info.EpisodeEnd = lastNumber(name[loc[6]:loc[7]], info.Episode)
+	info.setShow(name[:loc[0]])
+	*tail = name[loc[1]:]
+	return true
+}
+
+func parseCross(name string, info *EpisodeInfo, tail *string) bool {
+	loc := crossRegex.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return false
+	}
+	info.Season = atoi(name[loc[2]:loc[3]])
+	info.Episode = atoi(name[loc[4]:loc[5]])
+	info.EpisodeEnd = lastNumber(name[loc[6]:loc[7]], info.Episode)
+	info.setShow(name[:loc[0]])
+	*tail = name[loc[7]:]
+	return true
+}
+
+func parseAirDate(name string, info *EpisodeInfo, tail *string) bool {
+	for _, loc := range airDateRegex.FindAllStringSubmatchIndex(name, -1) {
+		date, err := time.Parse("2006-01-02", name[loc[2]:loc[3]]+"-"+name[loc[4]:loc[5]]+"-"+name[loc[6]:loc[7]])
+		if err != nil {
+			continue
+		}
+		info.AirDate = date
+		info.Season = date.Year()
+		info.Episode = int(date.Month())*100 + date.Day()
+		info.EpisodeEnd = info.Episode
+		info.setShow(name[:loc[0]])
+		info.Year = date.Year()
+		*tail = name[loc[7]:]
+		return true
+	}
+	return false
+}
+
+func parseAbsolute(name string, info *EpisodeInfo, tail *string) bool {
+	m := absoluteRegex.FindStringSubmatchIndex(name)
+	if m == nil {
+		return false
+	}
+	number := atoi(name[m[4]:m[5]])
+	// "Movie - 2019 [1080p]" is a year, not the 2019th episode.
+	if number == 0 || (number >= 1900 && number < 2100) {
+		return false
+	}
+	info.Season = 1
+	info.Episode = number
+	info.EpisodeEnd = number
+	info.AbsoluteEpisode = number
+	info.setShow(name[m[2]:m[3]])
+	*tail = name[m[5]:]
+	return true
+}
+
+func parseBareEpisode(filePath, name string, info *EpisodeInfo, tail *string) bool {
+	loc := bareEpisodeRegex.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return false
+	}
+
+	season, ok := seasonFromDir(filePath)
+	if !ok {
+		// Without a season folder a lone number is too ambiguous.
+		return false
+	}
+	show := showFromDir(filePath)
+
+	info.Season = season
+	info.Episode = atoi(name[loc[2]:loc[3]])
+	info.EpisodeEnd = info.Episode
+	// Prefer a show name in the filename ("Show - E05") over the folder.
+	if prefix := cleanShowName(name[:loc[0]]); prefix != "" {
+		show = name[:loc[0]]
+	}
+	info.setShow(show)
+	*tail = name[loc[1]:]
+	return true
+}
+
+// setShow cleans the text in front of the episode number and splits off a trailing year.
+func (e *EpisodeInfo) setShow(raw string) {
+	e.ShowName = cleanShowName(raw)
+	if m := showYearRegex.FindStringSubmatch(e.ShowName); m != nil {
+		e.ShowName = m[1]
+		e.Year = atoi(m[2])
+	}
+}
+
+func cleanShowName(raw string) string {
+	raw = trailingTagsRegex.ReplaceAllString(raw, "")
+	raw = strings.ReplaceAll(raw, ".", " ")
+	raw = strings.ReplaceAll(raw, "_", " ")
+	raw = strings.Join(strings.Fields(raw), " ")
+	return strings.Trim(raw, " -")
+}
+
+func stripExtension(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if videoExtensions[ext] || subtitleExtensions[ext] {
+		return strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	return filename
+}
+
+// lastNumber returns the last number in the multi-episode part of a match, or def if there is none.
+func lastNumber(s string, def int) int {
+	last := def
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r < '0' || r > '9' }) {
+		last = atoi(f)
+	}
+	return last
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,108 @@
+package ff
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// parsedEpisode is the golden form of EpisodeInfo, nil when the filename must not parse.
+type parsedEpisode struct {
+	ShowName        string `json:"show"`
+	Season          int    `json:"season"`
+	Episode         int    `json:"episode"`
+	EpisodeEnd      int    `json:"episode_end"`
+	AbsoluteEpisode int    `json:"absolute,omitempty"`
+	AirDate         string `json:"air_date,omitempty"`
+	Year            int    `json:"year,omitempty"`
+	Resolution      int    `json:"resolution,omitempty"`
+	ReleaseGroup    string `json:"group,omitempty"`
+}
+
+type goldenCase struct {
+	Filename string         `json:"filename"`
+	Parsed   *parsedEpisode `json:"parsed"`
+}
+
+func TestParseEpisodeInfoGolden(t *testing.T) {
+	input, err := os.Open(filepath.Join("testdata", "episodes.txt"))
+	require.NoError(t, err)
+	defer input.Close()
+
+	var got []goldenCase
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		c := goldenCase{Filename: line}
+		// The corpus uses forward slashes, make them native so folders are recognized everywhere.
+		if info, err := ParseEpisodeInfo(filepath.FromSlash(line)); err == nil {
+			c.Parsed = &parsedEpisode{
+				ShowName:        info.ShowName,
+				Season:          info.Season,
+				Episode:         info.Episode,
+				EpisodeEnd:      info.EpisodeEnd,
+				AbsoluteEpisode: info.AbsoluteEpisode,
+				Year:            info.Year,
+				Resolution:      info.Resolution,
+				ReleaseGroup:    info.ReleaseGroup,
+			}
+			if !info.AirDate.IsZero() {
+				c.Parsed.AirDate = info.AirDate.Format("2006-01-02")
+			}
+		}
+		got = append(got, c)
+	}
+	require.NoError(t, scanner.Err())
+
+	goldenPath := filepath.Join("testdata", "episodes.golden.json")
+	if *update {
+		data, err := json.MarshalIndent(got, "", "  ")
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(goldenPath, append(data, '\n'), 0644))
+	}
+
+	data, err := os.ReadFile(goldenPath)
+	require.NoError(t, err)
+	var want []goldenCase
+	require.NoError(t, json.Unmarshal(data, &want))
+
+	require.Len(t, got, len(want), "corpus and golden file are out of sync, run with -update")
+	for i := range want {
+		assert.Equal(t, want[i], got[i], want[i].Filename)
+	}
+}
+
+func TestFindNextEpisodeInFilesSkipsMultiEpisodeRange(t *testing.T) {
+	target, err := ParseEpisodeInfo("Lost.S01E01E02.Pilot.mkv")
+	require.NoError(t, err)
+
+	next, found := FindNextEpisodeInFiles(target, []string{"Lost.S01E02.mkv", "Lost.S01E03.mkv"})
+	require.True(t, found)
+	assert.Equal(t, 3, next.Episode)
+}
+
+func TestFindNextEpisodeInFilesByAirDate(t *testing.T) {
+	target, err := ParseEpisodeInfo("The.Daily.Show.2024.03.15.720p.mkv")
+	require.NoError(t, err)
+
+	next, found := FindNextEpisodeInFiles(target, []string{
+		"The.Daily.Show.2024.04.01.720p.mkv",
+		"The.Daily.Show.2024.03.18.720p.mkv",
+		"The.Daily.Show.2023.12.20.720p.mkv",
+	})
+	require.True(t, found)
+	assert.Equal(t, "The.Daily.Show.2024.03.18.720p.mkv", next.FilePath)
+}
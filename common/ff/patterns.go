@@ -0,0 +1,187 @@
+package ff
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Named groups a custom pattern may capture.
+const (
+	GroupShow     = "show"
+	GroupSeason   = "season"
+	GroupEpisode  = "episode"
+	GroupAbsolute = "absolute"
+	GroupDate     = "date"
+)
+
+var knownGroups = map[string]bool{GroupShow: true, GroupSeason: true, GroupEpisode: true, GroupAbsolute: true, GroupDate: true}
+
+// dateLayouts are the formats accepted in a date group.
+var dateLayouts = []string{"2006-01-02", "2006.01.02", "2006_01_02", "2006 01 02", "20060102"}
+
+// Pattern is a user defined filename pattern with named groups, e.g.
+// `^(?P<show>.+?) - (?P<season>\d+)\.(?P<episode>\d+)`.
+// It is matched against the filename without its extension.
+type Pattern struct {
+	Regexp *regexp.Regexp
+}
+
+// CompilePattern compiles a custom pattern and checks that its groups make sense.
+func CompilePattern(expr string) (*Pattern, error) {
+	r, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	has := make(map[string]bool)
+	for _, name := range r.SubexpNames()[1:] {
+		if name == "" {
+			continue
+		}
+		if !knownGroups[name] {
+			return nil, fmt.Errorf("unknown group '%s', expected one of show, season, episode, absolute, date", name)
+		}
+		has[name] = true
+	}
+
+	if !has[GroupEpisode] && !has[GroupAbsolute] && !has[GroupDate] {
+		return nil, errors.New("pattern needs an episode, absolute or date group")
+	}
+	if has[GroupSeason] && !has[GroupEpisode] {
+		return nil, errors.New("a season group needs an episode group")
+	}
+	return &Pattern{Regexp: r}, nil
+}
+
+// Group returns the start and end of a named group in the match m, or -1, -1 if it did not participate.
+func (p *Pattern) Group(m []int, group string) (int, int) {
+	i := p.Regexp.SubexpIndex(group)
+	if i < 0 || m[2*i] < 0 {
+		return -1, -1
+	}
+	return m[2*i], m[2*i+1]
+}
+
+var customPatterns atomic.Pointer[[]*Pattern]
+
+// SetCustomPatterns sets the user defined patterns, tried in order before the built-in ones.
+func SetCustomPatterns(patterns []*Pattern) {
+	customPatterns.Store(&patterns)
+}
+
+// CustomPatterns returns the user defined patterns.
+func CustomPatterns() []*Pattern {
+	if p := customPatterns.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// StripExtension removes a video or subtitle extension from a filename, the text custom patterns are matched against.
+func StripExtension(filename string) string {
+	return stripExtension(filename)
+}
+
+// parseCustom tries the user defined patterns on name.
+func parseCustom(filePath, name string, info *EpisodeInfo, tail *string) bool {
+	for _, p := range CustomPatterns() {
+		m := p.Regexp.FindStringSubmatchIndex(name)
+		if m == nil {
+			continue
+		}
+
+		text := func(group string) string {
+			start, end := p.Group(m, group)
+			if start < 0 {
+				return ""
+			}
+			return name[start:end]
+		}
+
+		switch {
+		case text(GroupEpisode) != "":
+			info.Episode = atoi(text(GroupEpisode))
+			info.Season = 1
+			if season := text(GroupSeason); season != "" {
+				info.Season = atoi(season)
+			} else if season, ok := seasonFromDir(filePath); ok {
+				info.Season = season
+			}
+		case text(GroupAbsolute) != "":
+			info.Season = 1
+			info.Episode = atoi(text(GroupAbsolute))
+			info.AbsoluteEpisode = info.Episode
+		case text(GroupDate) != "":
+			date, ok := parseDate(text(GroupDate))
+			if !ok {
+				continue
+			}
+			info.AirDate = date
+			info.Year = date.Year()
+			info.Season = date.Year()
+			info.Episode = int(date.Month())*100 + date.Day()
+		default:
+			continue
+		}
+		info.EpisodeEnd = info.Episode
+
+		// Without a show group, the show is named in front of the match or by the folders.
+		show := text(GroupShow)
+		if show == "" {
+			show = name[:m[0]]
+		}
+		if cleanShowName(show) == "" {
+			show = showFromDir(filePath)
+		}
+		info.setShow(show)
+		// setShow may have found a year in the show name, an air date wins over it.
+		if !info.AirDate.IsZero() {
+			info.Year = info.AirDate.Year()
+		}
+		*tail = name[m[1]:]
+		return true
+	}
+	return false
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// seasonFromDir returns the season of a "Season 2" or "Show.S02" folder.
+func seasonFromDir(filePath string) (int, bool) {
+	dir := filepath.Base(filepath.Dir(filePath))
+	if m := SeasonDirRegex.FindStringSubmatch(dir); m != nil {
+		return atoi(m[1]), true
+	}
+	if m := showSeasonDirRegex.FindStringSubmatch(dir); m != nil {
+		return atoi(m[2]), true
+	}
+	return 0, false
+}
+
+// showFromDir returns the show name of the folders above a file, skipping a season folder.
+func showFromDir(filePath string) string {
+	dir := filepath.Dir(filePath)
+	name := filepath.Base(dir)
+	if dir == "." {
+		return ""
+	}
+	if SeasonDirRegex.MatchString(name) {
+		return filepath.Base(filepath.Dir(dir))
+	}
+	if m := showSeasonDirRegex.FindStringSubmatch(name); m != nil {
+		return m[1]
+	}
+	return name
+}
@@ -0,0 +1,76 @@
+package ff
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func useCustomPatterns(t *testing.T, exprs ...string) {
+	var patterns []*Pattern
+	for _, expr := range exprs {
+		p, err := CompilePattern(expr)
+		require.NoError(t, err)
+		patterns = append(patterns, p)
+	}
+	SetCustomPatterns(patterns)
+	t.Cleanup(func() { SetCustomPatterns(nil) })
+}
+
+func TestCompilePatternRejectsInvalidPatterns(t *testing.T) {
+	tests := map[string]string{
+		"syntax error":        `(?P<episode>\d+`,
+		"unknown group":       `(?P<show>.+) (?P<ep>\d+)`,
+		"no episode":          `(?P<show>.+) - (?P<season>\d+)`,
+		"season without ep":   `(?P<season>\d+) (?P<absolute>\d+)`,
+		"only a show is kept": `^(?P<show>.+)$`,
+	}
+	for name, expr := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := CompilePattern(expr)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := CompilePattern(`^(?P<show>.+?) (?P<date>\d{8})`)
+	assert.NoError(t, err)
+}
+
+func TestParseEpisodeInfoCustomPatterns(t *testing.T) {
+	useCustomPatterns(t,
+		`^(?P<show>.+?) - (?P<season>\d+)\.(?P<episode>\d+)`,
+		`^(?P<show>.+?) #(?P<absolute>\d+)`,
+		`^(?P<show>.+?) aired (?P<date>\d{8})`,
+		`^Folge (?P<episode>\d+)`,
+		`[ .](?P<season>\d+)-(?P<episode>\d{2})$`,
+	)
+
+	tests := []struct {
+		path string
+		want EpisodeInfo
+	}{
+		{"Tatort - 3.14 - Der Fall.mkv", EpisodeInfo{ShowName: "Tatort", Season: 3, Episode: 14, EpisodeEnd: 14}},
+		{"One Piece #1071 [1080p].mkv", EpisodeInfo{ShowName: "One Piece", Season: 1, Episode: 1071, EpisodeEnd: 1071, AbsoluteEpisode: 1071, Resolution: 1080}},
+		{filepath.FromSlash("/tv/Die Sendung mit der Maus/Folge 7.mkv"), EpisodeInfo{ShowName: "Die Sendung mit der Maus", Season: 1, Episode: 7, EpisodeEnd: 7}},
+		{filepath.FromSlash("/tv/Die Sendung mit der Maus/Season 2/Folge 7.mkv"), EpisodeInfo{ShowName: "Die Sendung mit der Maus", Season: 2, Episode: 7, EpisodeEnd: 7}},
+		{"Show.Name.3-14.mkv", EpisodeInfo{ShowName: "Show Name", Season: 3, Episode: 14, EpisodeEnd: 14}},
+		// Built-in patterns still work when no custom pattern matches.
+		{"The.Bear.S01E05.1080p.WEB.H264-CAKES.mkv", EpisodeInfo{ShowName: "The Bear", Season: 1, Episode: 5, EpisodeEnd: 5, Resolution: 1080, ReleaseGroup: "CAKES"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ParseEpisodeInfo(tt.path)
+			require.NoError(t, err)
+			tt.want.FilePath = tt.path
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := ParseEpisodeInfo("Tagesschau aired 20240315.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, "Tagesschau", got.ShowName)
+	assert.Equal(t, "2024-03-15", got.AirDate.Format("2006-01-02"))
+	assert.Equal(t, 2024, got.Year)
+}
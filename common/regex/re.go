@@ -2,49 +2,265 @@ package re
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"villain-couch/common/ff"
 )
 
+// episodeRegex finds patterns like S01E06, s01e06, S1E6 or S01E100.
+// It captures the parts: (S)(##)(E)(##)
+var episodeRegex = regexp.MustCompile(`(?i)(S)(\d{1,3})(E)(\d{1,3})`)
+
+// episodeName is a filename with the position of its season and episode numbers.
+type episodeName struct {
+	filename                 string
+	seasonStart, seasonEnd   int // -1 when the name has no season number, e.g. absolute numbering
+	episodeStart, episodeEnd int
+	season, episode          int
+	show                     string // normalized show name, to compare files
+}
+
+// parseEpisodeName tries the custom patterns of ff first, then the built-in S01E02 pattern.
+func parseEpisodeName(filename string) (episodeName, bool) {
+	stem := ff.StripExtension(filename)
+	for _, p := range ff.CustomPatterns() {
+		m := p.Regexp.FindStringSubmatchIndex(stem)
+		if m == nil {
+			continue
+		}
+		start, end := p.Group(m, ff.GroupEpisode)
+		if start < 0 {
+			start, end = p.Group(m, ff.GroupAbsolute)
+		}
+		if start < 0 {
+			// Date based names cannot be incremented.
+			continue
+		}
+		n := episodeName{filename: filename, seasonStart: -1, seasonEnd: -1, episodeStart: start, episodeEnd: end}
+		n.seasonStart, n.seasonEnd = p.Group(m, ff.GroupSeason)
+		if showStart, showEnd := p.Group(m, ff.GroupShow); showStart >= 0 {
+			n.show = normalizePrefix(stem[showStart:showEnd])
+		} else {
+			first := start
+			if n.seasonStart >= 0 && n.seasonStart < first {
+				first = n.seasonStart
+			}
+			n.show = normalizePrefix(stem[:first])
+		}
+		if n.parseNumbers() {
+			return n, true
+		}
+	}
+
+	loc := episodeRegex.FindStringSubmatchIndex(filename)
+	if loc == nil {
+		return episodeName{}, false
+	}
+	n := episodeName{
+		filename:     filename,
+		seasonStart:  loc[4],
+		seasonEnd:    loc[5],
+		episodeStart: loc[8],
+		episodeEnd:   loc[9],
+		show:         normalizePrefix(filename[:loc[0]]),
+	}
+	return n, n.parseNumbers()
+}
+
+func (n *episodeName) parseNumbers() bool {
+	var err error
+	if n.seasonStart >= 0 {
+		if n.season, err = strconv.Atoi(n.filename[n.seasonStart:n.seasonEnd]); err != nil {
+			return false
+		}
+	}
+	n.episode, err = strconv.Atoi(n.filename[n.episodeStart:n.episodeEnd])
+	return err == nil
+}
+
+func (n episodeName) hasSeason() bool {
+	return n.seasonStart >= 0
+}
+
+// with returns the filename of another episode, keeping everything else including the zero-padding.
+func (n episodeName) with(season, episode int) string {
+	episodeStr := fmt.Sprintf("%0*d", n.episodeEnd-n.episodeStart, episode)
+	if !n.hasSeason() {
+		return n.filename[:n.episodeStart] + episodeStr + n.filename[n.episodeEnd:]
+	}
+
+	seasonStr := fmt.Sprintf("%0*d", n.seasonEnd-n.seasonStart, season)
+	// Custom patterns may put the episode in front of the season.
+	if n.episodeStart < n.seasonStart {
+		return n.filename[:n.episodeStart] + episodeStr + n.filename[n.episodeEnd:n.seasonStart] + seasonStr + n.filename[n.seasonEnd:]
+	}
+	return n.filename[:n.seasonStart] + seasonStr + n.filename[n.seasonEnd:n.episodeStart] + episodeStr + n.filename[n.episodeEnd:]
+}
+
+// normalizePrefix makes show names comparable, e.g. "The.Bear." and "the bear - " are the same show.
+func normalizePrefix(prefix string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '_', ' ', '-':
+			return -1
+		}
+		return r
+	}, strings.ToLower(prefix))
+}
+
 // GetNextEpisodeFilename attempts to find a season/episode pattern (e.g., S01E06)
 // in a filename and returns the filename for the next episode.
 // It returns the new filename and a boolean indicating if the pattern was found.
+//
+// The custom patterns of ff are tried before the built-in S01E06 pattern.
+// The next episode of the same season is tried first, then the first episode of the next season,
+// in the same directory and in sibling "Season N" folders. Files of another release (e.g. a different
+// group suffix) are accepted if the show and the episode match. If no such file exists, the path with
+// the incremented episode number is returned.
 func GetNextEpisodeFilename(currentFilename string) (string, bool) {
 	// Separate the directory and the filename.
 	dir := filepath.Dir(currentFilename)
 	filename := filepath.Base(currentFilename)
 
-	// Regex to find patterns like S01E06, s01e06, etc.
-	// It captures the parts: (S##)(E)(##)
-	re := regexp.MustCompile(`(?i)(S\d{2})(E)(\d{2})`)
-
-	// Find the first match in the filename.
-	submatches := re.FindStringSubmatch(filename)
-	if len(submatches) != 4 {
+	current, ok := parseEpisodeName(filename)
+	if !ok {
 		// Pattern not found.
 		return "", false
 	}
 
-	episodeStr := submatches[3]
-	episodeNum, err := strconv.Atoi(episodeStr)
+	nextPath := filepath.Join(dir, current.with(current.season, current.episode+1))
+	if fileExists(nextPath) {
+		return nextPath, true
+	}
+	if found, ok := findEpisode(dir, current, current.season, current.episode+1); ok {
+		return found, true
+	}
+
+	// Season rollover: the first episode of the next season, next to the current one or in its own folder.
+	if !current.hasSeason() {
+		return nextPath, true
+	}
+	nextSeason := current.season + 1
+	if found, ok := findEpisode(dir, current, nextSeason, 1); ok {
+		return found, true
+	}
+	for _, seasonDir := range siblingSeasonDirs(dir, nextSeason) {
+		if found, ok := findEpisode(seasonDir, current, nextSeason, 1); ok {
+			return found, true
+		}
+	}
+
+	return nextPath, true
+}
+
+// GetPreviousEpisodeFilename returns the episode before currentFilename: the previous episode of the same season,
+// or the last episode of the previous season, next to the current one or in its own folder.
+// Unlike GetNextEpisodeFilename, it only returns files that exist.
+func GetPreviousEpisodeFilename(currentFilename string) (string, bool) {
+	dir := filepath.Dir(currentFilename)
+	current, ok := parseEpisodeName(filepath.Base(currentFilename))
+	if !ok {
+		return "", false
+	}
+
+	if current.episode > 1 {
+		if found, ok := findEpisode(dir, current, current.season, current.episode-1); ok {
+			return found, true
+		}
+	}
+
+	if !current.hasSeason() || current.season <= 1 {
+		return "", false
+	}
+	previousSeason := current.season - 1
+	if found, ok := findLastEpisode(dir, current, previousSeason); ok {
+		return found, true
+	}
+	for _, seasonDir := range siblingSeasonDirs(dir, previousSeason) {
+		if found, ok := findLastEpisode(seasonDir, current, previousSeason); ok {
+			return found, true
+		}
+	}
+	return "", false
+}
+
+// findEpisode looks in dir for a video file of the same show with the given season and episode.
+// A file named exactly like the current one is preferred over other releases.
+func findEpisode(dir string, current episodeName, season, episode int) (string, bool) {
+	if exact := filepath.Join(dir, current.with(season, episode)); fileExists(exact) {
+		return exact, true
+	}
+
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		// This should not happen with our regex, but it's good practice to check.
 		return "", false
 	}
+	for _, entry := range entries {
+		if entry.IsDir() || !ff.IsVideoFile(entry.Name()) {
+			continue
+		}
+		candidate, ok := parseEpisodeName(entry.Name())
+		if !ok {
+			continue
+		}
+		if candidate.show == current.show && candidate.season == season && candidate.episode == episode {
+			return filepath.Join(dir, entry.Name()), true
+		}
+	}
+	return "", false
+}
 
-	// Increment the episode number.
-	nextEpisodeNum := episodeNum + 1
+// findLastEpisode looks in dir for the video file of the same show with the highest episode of a season.
+func findLastEpisode(dir string, current episodeName, season int) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
 
-	// Format the new episode number back to a two-digit string (e.g., "07").
-	nextEpisodeStr := fmt.Sprintf("%02d", nextEpisodeNum)
+	last, lastEpisode := "", 0
+	for _, entry := range entries {
+		if entry.IsDir() || !ff.IsVideoFile(entry.Name()) {
+			continue
+		}
+		candidate, ok := parseEpisodeName(entry.Name())
+		if !ok || candidate.show != current.show || candidate.season != season {
+			continue
+		}
+		if candidate.episode > lastEpisode {
+			last, lastEpisode = filepath.Join(dir, entry.Name()), candidate.episode
+		}
+	}
+	return last, last != ""
+}
 
-	// Use the regex to replace only the episode number part of the matched string.
-	// ${1} is the season part (e.g., "S01"), ${2} is "E".
-	nextFilename := re.ReplaceAllString(filename, "${1}${2}"+nextEpisodeStr)
+// siblingSeasonDirs returns the folders next to dir that are named after the given season.
+func siblingSeasonDirs(dir string, season int) []string {
+	parent := filepath.Dir(dir)
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return nil
+	}
 
-	// Combine the original directory with the new filename.
-	nextPath := filepath.Join(dir, nextFilename)
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		matches := ff.SeasonDirRegex.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(matches[1]); err == nil && n == season {
+			dirs = append(dirs, filepath.Join(parent, entry.Name()))
+		}
+	}
+	return dirs
+}
 
-	return nextPath, true
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
 }
@@ -1,9 +1,13 @@
 package re
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"villain-couch/common/ff"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestValidateAndUnwrapErrors tests that our validate function works as expected
@@ -26,3 +30,206 @@ func TestGetNextEpisodeFilename(t *testing.T) {
 	assert.Equal(t, expected, got)
 
 }
+
+func TestGetNextEpisodeFilenameOnDisk(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string // relative to the show directory
+		current string
+		want    string
+	}{
+		{
+			name:    "next episode in the same season",
+			files:   []string{"Season 1/Show.S01E01.mkv", "Season 1/Show.S01E02.mkv"},
+			current: "Season 1/Show.S01E01.mkv",
+			want:    "Season 1/Show.S01E02.mkv",
+		},
+		{
+			name:    "next episode of another release group",
+			files:   []string{"Show.S01E01.1080p.WEB.H264-CAKES.mkv", "Show.S01E02.720p.HDTV.x264-KILLERS.mp4", "Show.S01E02.720p.HDTV.x264-KILLERS.srt"},
+			current: "Show.S01E01.1080p.WEB.H264-CAKES.mkv",
+			want:    "Show.S01E02.720p.HDTV.x264-KILLERS.mp4",
+		},
+		{
+			name:    "same release group is preferred",
+			files:   []string{"Show.S01E01-A.mkv", "Show.S01E02-B.mkv", "Show.S01E02-A.mkv"},
+			current: "Show.S01E01-A.mkv",
+			want:    "Show.S01E02-A.mkv",
+		},
+		{
+			name:    "season rollover in the same directory",
+			files:   []string{"Show.S01E10.mkv", "Show.S02E01.mkv"},
+			current: "Show.S01E10.mkv",
+			want:    "Show.S02E01.mkv",
+		},
+		{
+			name:    "season rollover into a sibling season folder",
+			files:   []string{"Season 1/Show.S01E10.1080p-A.mkv", "Season 02/Show.S02E01.720p-B.mkv"},
+			current: "Season 1/Show.S01E10.1080p-A.mkv",
+			want:    "Season 02/Show.S02E01.720p-B.mkv",
+		},
+		{
+			name:    "next episode wins over the next season",
+			files:   []string{"Season 1/Show.S01E10.mkv", "Season 1/Show.S01E11.mkv", "Season 2/Show.S02E01.mkv"},
+			current: "Season 1/Show.S01E10.mkv",
+			want:    "Season 1/Show.S01E11.mkv",
+		},
+		{
+			name:    "other shows are ignored",
+			files:   []string{"Show.S01E10.mkv", "Other.Show.S02E01.mkv"},
+			current: "Show.S01E10.mkv",
+			want:    "Show.S01E11.mkv",
+		},
+		{
+			name:    "zero-padding is kept",
+			files:   []string{"Show.S1E9.mkv"},
+			current: "Show.S1E9.mkv",
+			want:    "Show.S1E10.mkv",
+		},
+		{
+			name:    "three digit episodes",
+			files:   []string{"Show.S01E099.mkv", "Show.S01E100.mkv"},
+			current: "Show.S01E099.mkv",
+			want:    "Show.S01E100.mkv",
+		},
+		{
+			name:    "nothing on disk falls back to the next episode",
+			files:   []string{"Season 1/Show.S01E10.mkv"},
+			current: "Season 1/Show.S01E10.mkv",
+			want:    "Season 1/Show.S01E11.mkv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(root, filepath.FromSlash(f))
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, nil, 0o644))
+			}
+
+			got, ok := GetNextEpisodeFilename(filepath.Join(root, filepath.FromSlash(tt.current)))
+			assert.True(t, ok)
+			assert.Equal(t, filepath.Join(root, filepath.FromSlash(tt.want)), got)
+		})
+	}
+}
+
+func TestGetNextEpisodeFilenameWithoutPattern(t *testing.T) {
+	_, ok := GetNextEpisodeFilename("Some.Movie.2019.1080p.mkv")
+	assert.False(t, ok)
+}
+
+func TestGetNextEpisodeFilenameCustomPatterns(t *testing.T) {
+	var patterns []*ff.Pattern
+	for _, expr := range []string{`^(?P<show>.+?) - (?P<season>\d+)\.(?P<episode>\d+)`, `^(?P<show>.+?) #(?P<absolute>\d+)`} {
+		p, err := ff.CompilePattern(expr)
+		require.NoError(t, err)
+		patterns = append(patterns, p)
+	}
+	ff.SetCustomPatterns(patterns)
+	t.Cleanup(func() { ff.SetCustomPatterns(nil) })
+
+	tests := []struct {
+		name    string
+		files   []string
+		current string
+		want    string
+	}{
+		{
+			name:    "next episode",
+			files:   []string{"Tatort - 3.09 - A.mkv", "Tatort - 3.10 - B.mkv"},
+			current: "Tatort - 3.09 - A.mkv",
+			want:    "Tatort - 3.10 - B.mkv",
+		},
+		{
+			name:    "season rollover",
+			files:   []string{"Tatort - 3.10 - A.mkv", "Tatort - 4.01 - B.mkv"},
+			current: "Tatort - 3.10 - A.mkv",
+			want:    "Tatort - 4.01 - B.mkv",
+		},
+		{
+			name:    "absolute numbering",
+			files:   []string{"One Piece #099.mkv"},
+			current: "One Piece #099.mkv",
+			want:    "One Piece #100.mkv",
+		},
+		{
+			name:    "built-in pattern when no custom pattern matches",
+			files:   []string{"Show.S01E01.mkv"},
+			current: "Show.S01E01.mkv",
+			want:    "Show.S01E02.mkv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, f := range tt.files {
+				require.NoError(t, os.WriteFile(filepath.Join(root, f), nil, 0o644))
+			}
+
+			got, ok := GetNextEpisodeFilename(filepath.Join(root, tt.current))
+			assert.True(t, ok)
+			assert.Equal(t, filepath.Join(root, tt.want), got)
+		})
+	}
+}
+
+func TestGetPreviousEpisodeFilename(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string // relative to the show directory
+		current string
+		want    string // empty when there is no previous episode
+	}{
+		{
+			name:    "previous episode in the same season",
+			files:   []string{"Season 1/Show.S01E01.mkv", "Season 1/Show.S01E02.mkv"},
+			current: "Season 1/Show.S01E02.mkv",
+			want:    "Season 1/Show.S01E01.mkv",
+		},
+		{
+			name:    "previous episode of another release group",
+			files:   []string{"Show.S01E01.720p-B.mkv", "Show.S01E02.1080p-A.mkv"},
+			current: "Show.S01E02.1080p-A.mkv",
+			want:    "Show.S01E01.720p-B.mkv",
+		},
+		{
+			name:    "last episode of the previous season in a sibling folder",
+			files:   []string{"Season 1/Show.S01E09.mkv", "Season 1/Show.S01E10.mkv", "Season 2/Show.S02E01.mkv"},
+			current: "Season 2/Show.S02E01.mkv",
+			want:    "Season 1/Show.S01E10.mkv",
+		},
+		{
+			name:    "first episode of the show",
+			files:   []string{"Show.S01E01.mkv"},
+			current: "Show.S01E01.mkv",
+		},
+		{
+			name:    "missing previous episode",
+			files:   []string{"Show.S01E03.mkv"},
+			current: "Show.S01E03.mkv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(root, filepath.FromSlash(f))
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+				require.NoError(t, os.WriteFile(path, nil, 0o644))
+			}
+
+			got, ok := GetPreviousEpisodeFilename(filepath.Join(root, filepath.FromSlash(tt.current)))
+			if tt.want == "" {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, filepath.Join(root, filepath.FromSlash(tt.want)), got)
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package str
+
+import "fmt"
+
+// Clock formats seconds as HH:MM:SS.
+func Clock(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds%3600)/60, seconds%60)
+}
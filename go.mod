@@ -3,6 +3,7 @@ module villain-couch
 go 1.25
 
 require (
+	github.com/fsnotify/fsnotify v1.10.1
 	github.com/stretchr/testify v1.11.1
 	golang.org/x/sys v0.37.0
 	modernc.org/sqlite v1.39.0